@@ -2,13 +2,11 @@ package assets
 
 import (
 	"embed"
-	"image"
 	_ "image/png"
 	"io/fs"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"golang.org/x/image/font"
-	"golang.org/x/image/font/opentype"
 )
 
 //go:embed *
@@ -22,11 +20,6 @@ var HighSpeedFollowPlayerEnemySprite = MustLoadImage("img/Ships/enemy5.png")
 var LowSpeedEnemyLightMissile = MustLoadImage("img/Ships/enemy4.png")
 var LowSpeedEnemyAutoLightMissile = MustLoadImage("img/Ships/enemy3.png")
 
-// ships
-var ShipMightyOrcaSprite = MustLoadImage("img/Ships/ship2.png")
-var ShipShadyWeaselSprite = MustLoadImage("img/Ships/ship5.png")
-var ShipAngryOcelotSprite = MustLoadImage("img/Ships/ship4.png")
-
 // items
 var Heal = MustLoadImage("img/Items/heal_item.png")
 var ItemMissileSprite = MustLoadImage("img/Items/missile_item.png")
@@ -76,16 +69,6 @@ var EnemyMidMissile = MustLoadImage("img/Missiles/missile_projectile3.png")
 // backgrounds
 var Backgrounds = mustLoadImages("img/Backgrounds/*.png")
 
-// spritesheets
-var EnemyBlowSpriteSheet = MustLoadImage("img/Effects/blow.png")
-var BigBlowSpriteSheet = MustLoadImage("img/Effects/bigblow.png")
-var PlayerFireburstSpriteSheet = MustLoadImage("img/Effects/fire1.png")
-var ShieldSpriteSheet = MustLoadImage("img/Ships/shieldSpriteSheet1.png")
-var ProjectileBlowSpriteSheet = MustLoadImage("img/Effects/projectile_blow.png")
-var LightMissileBlowSpriteSheet = MustLoadImage("img/Effects/light_missile_blow2.png")
-var ClusterMinesBlowSpriteSheet = MustLoadImage("img/Effects/cluster_mines_blow.png")
-var PlasmaGunProjectileSpriteSheet = MustLoadImage("img/Effects/plasma_gun1.png")
-
 // fonts
 var ScoreFont1024x768 = mustLoadFont("fonts/Kenney Pixel.ttf", 48)
 var InfoFont1024x768 = mustLoadFont("fonts/Kenney Future.ttf", 18)
@@ -100,67 +83,63 @@ var SmallFont1920x1080 = mustLoadFont("fonts/Kenney Mini.ttf", 36)
 var ProfileFont1920x1080 = mustLoadFont("fonts/Kenney Future.ttf", 20)
 var ProfileBigFont1920x1080 = mustLoadFont("fonts/Kenney Future.ttf", 42)
 
-func LoadAsImage(name string) image.Image {
-	f, err := assets.Open(name)
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-	img, _, err := image.Decode(f)
-	if err != nil {
-		panic(err)
-	}
-	return img
-}
+var manager = newDefaultManager()
 
+// MustLoadImage loads an image the game can't do without. It only panics
+// when the built-in file itself is broken, a broken mod file falls back to it.
 func MustLoadImage(name string) *ebiten.Image {
-	f, err := assets.Open(name)
+	img, err := LoadImage(name)
 	if err != nil {
 		panic(err)
 	}
-	defer f.Close()
+	return img
+}
 
-	img, _, err := image.Decode(f)
-	if err != nil {
-		panic(err)
-	}
+// LoadImage decodes the image with the asset name. Every name is decoded
+// once, so data files naming a sprite get the same image as the variables
+// here.
+func LoadImage(name string) (*ebiten.Image, error) {
+	return manager.Image(name)
+}
 
-	return ebiten.NewImageFromImage(img)
+// LoadFont returns a face of the font with the asset name.
+func LoadFont(name string, size float64) (font.Face, error) {
+	return manager.Font(name, size)
 }
 
-func mustLoadImages(path string) []*ebiten.Image {
-	matches, err := fs.Glob(assets, path)
-	if err != nil {
-		panic(err)
-	}
+// ReadFile returns the content of a data file.
+func ReadFile(name string) ([]byte, error) {
+	return manager.ReadFile(name)
+}
 
-	images := make([]*ebiten.Image, len(matches))
-	for i, match := range matches {
-		images[i] = MustLoadImage(match)
-	}
+// LoadData parses a data file, falling back to the built-in file when the
+// one of a mod pack is refused.
+func LoadData(name string, parse func(data []byte) error) error {
+	return manager.Data(name, parse)
+}
 
-	return images
+// FS gives access to every asset, mod files included.
+func FS() fs.FS {
+	return manager
 }
 
-func mustLoadFont(name string, size float64) font.Face {
-	f, err := assets.ReadFile(name)
-	if err != nil {
-		panic(err)
-	}
+// Errors returns the problems found in the enabled mod packs.
+func Errors() []error {
+	return manager.Errors()
+}
 
-	tt, err := opentype.Parse(f)
+func mustLoadImages(pattern string) []*ebiten.Image {
+	images, err := manager.Images(pattern)
 	if err != nil {
 		panic(err)
 	}
+	return images
+}
 
-	face, err := opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    size,
-		DPI:     72,
-		Hinting: font.HintingVertical,
-	})
+func mustLoadFont(name string, size float64) font.Face {
+	face, err := LoadFont(name, size)
 	if err != nil {
 		panic(err)
 	}
-
 	return face
 }
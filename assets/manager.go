@@ -0,0 +1,215 @@
+package assets
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+// layer is one place assets are looked up in, a mod pack or the files built
+// into the game.
+type layer struct {
+	name string
+	fsys fs.FS
+}
+
+// Manager resolves assets by their slash separated name, like
+// "img/Ships/ship5.png". Mod packs are searched first, in order, and the
+// built-in files last. A broken file in a pack is reported and the next layer
+// is used instead.
+type Manager struct {
+	layers []layer
+	images map[string]*ebiten.Image
+	fonts  map[string]*opentype.Font
+	errs   []error
+}
+
+// NewManager layers the pack directories, first one on top, over the built-in
+// assets. Packs that can't be read are reported by Errors.
+func NewManager(packDirs ...string) *Manager {
+	m := &Manager{
+		images: map[string]*ebiten.Image{},
+		fonts:  map[string]*opentype.Font{},
+	}
+	for _, dir := range packDirs {
+		info, err := os.Stat(dir)
+		if err == nil && !info.IsDir() {
+			err = fmt.Errorf("not a directory")
+		}
+		if err != nil {
+			m.errs = append(m.errs, fmt.Errorf("mod %s: %w", filepath.Base(dir), err))
+			continue
+		}
+		m.layers = append(m.layers, layer{name: "mod " + filepath.Base(dir), fsys: os.DirFS(dir)})
+	}
+	m.layers = append(m.layers, layer{name: "built-in", fsys: assets})
+	return m
+}
+
+// Errors returns the problems found in mod packs so far.
+func (m *Manager) Errors() []error {
+	return m.errs
+}
+
+// find calls try with the file of every layer holding name, from the top,
+// until one is accepted. Problems of mod files are recorded and skipped, a
+// problem of a built-in file is returned.
+func (m *Manager) find(name string, try func(f fs.File) error) error {
+	for i, l := range m.layers {
+		f, err := l.fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			err = try(f)
+			f.Close()
+		}
+		if err == nil {
+			return nil
+		}
+		err = fmt.Errorf("%s: %s: %w", l.name, name, err)
+		if i == len(m.layers)-1 {
+			return err
+		}
+		m.errs = append(m.errs, err)
+	}
+	return fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+}
+
+// Open implements fs.FS over the layered files.
+func (m *Manager) Open(name string) (fs.File, error) {
+	for _, l := range m.layers {
+		f, err := l.fsys.Open(name)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return f, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the directory of every layer, so globs see the files a pack
+// adds.
+func (m *Manager) ReadDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	found := false
+	for _, l := range m.layers {
+		list, err := fs.ReadDir(l.fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, e := range list {
+			if !slices.ContainsFunc(entries, func(o fs.DirEntry) bool { return o.Name() == e.Name() }) {
+				entries = append(entries, e)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// ReadFile returns the content of the topmost file with the name.
+func (m *Manager) ReadFile(name string) ([]byte, error) {
+	var data []byte
+	err := m.find(name, func(f fs.File) error {
+		var err error
+		data, err = io.ReadAll(f)
+		return err
+	})
+	return data, err
+}
+
+// Data hands the topmost file with the name to parse. When parse refuses a
+// pack's file the problem is recorded and the next layer is tried.
+func (m *Manager) Data(name string, parse func(data []byte) error) error {
+	return m.find(name, func(f fs.File) error {
+		data, err := io.ReadAll(f)
+		if err == nil {
+			err = parse(data)
+		}
+		return err
+	})
+}
+
+// Image decodes the image once, later calls get the same image.
+func (m *Manager) Image(name string) (*ebiten.Image, error) {
+	if img, ok := m.images[name]; ok {
+		return img, nil
+	}
+	err := m.find(name, func(f fs.File) error {
+		img, _, err := image.Decode(f)
+		if err != nil {
+			return err
+		}
+		m.images[name] = ebiten.NewImageFromImage(img)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.images[name], nil
+}
+
+// Images loads every image matching the glob pattern in name order. Images
+// only a pack has and that are broken are left out.
+func (m *Manager) Images(pattern string) ([]*ebiten.Image, error) {
+	matches, err := fs.Glob(m, pattern)
+	if err != nil {
+		return nil, err
+	}
+	var images []*ebiten.Image
+	var errs []error
+	for _, match := range matches {
+		img, err := m.Image(match)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		images = append(images, img)
+	}
+	return images, errors.Join(errs...)
+}
+
+// Font returns a face of the font at the given size.
+func (m *Manager) Font(name string, size float64) (font.Face, error) {
+	tt, ok := m.fonts[name]
+	if !ok {
+		err := m.find(name, func(f fs.File) error {
+			data, err := io.ReadAll(f)
+			if err == nil {
+				tt, err = opentype.Parse(data)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		m.fonts[name] = tt
+	}
+	return opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingVertical,
+	})
+}
@@ -0,0 +1,149 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	// AppDirName is the folder of the game in the per-user config directory
+	AppDirName = "astrogame"
+
+	modsFileName = "mods.json"
+	modsDirName  = "mods"
+	modsVersion  = 1
+)
+
+// Pack is a mod directory of the mods folder. Its files replace the built-in
+// ones with the same name.
+type Pack struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+type modSettings struct {
+	Version int    `json:"version"`
+	Packs   []Pack `json:"packs"`
+}
+
+func userPath(elem ...string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{dir, AppDirName}, elem...)...), nil
+}
+
+// ModsDir is where mod packs are installed, one directory per pack.
+func ModsDir() (string, error) {
+	return userPath(modsDirName)
+}
+
+// Packs lists the installed packs in load order, the first one on top. Packs
+// new in the mods folder come last and disabled, packs whose folder is gone
+// are dropped.
+func Packs() ([]Pack, error) {
+	dir, err := ModsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var installed []string
+	for _, e := range entries {
+		if e.IsDir() {
+			installed = append(installed, e.Name())
+		}
+	}
+
+	var settings modSettings
+	path, err := userPath(modsFileName)
+	if err == nil {
+		var data []byte
+		data, err = os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &settings)
+		}
+		if err == nil && settings.Version > modsVersion {
+			err = fmt.Errorf("unsupported version %d", settings.Version)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("mods: %w", err)
+		settings = modSettings{}
+	}
+
+	var packs []Pack
+	for _, p := range settings.Packs {
+		if slices.Contains(installed, p.Name) && !slices.ContainsFunc(packs, func(o Pack) bool { return o.Name == p.Name }) {
+			packs = append(packs, p)
+		}
+	}
+	for _, name := range installed {
+		if !slices.ContainsFunc(packs, func(p Pack) bool { return p.Name == name }) {
+			packs = append(packs, Pack{Name: name})
+		}
+	}
+	return packs, err
+}
+
+// SavePacks stores the order and the state of the packs. They are applied
+// the next time the game starts.
+func SavePacks(packs []Pack) error {
+	path, err := userPath(modsFileName)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(modSettings{Version: modsVersion, Packs: packs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// EnabledPacks returns the names of the packs the assets were loaded with.
+func EnabledPacks() []string {
+	return enabledPacks
+}
+
+var enabledPacks []string
+
+// newDefaultManager layers the enabled packs over the built-in assets.
+func newDefaultManager() *Manager {
+	packs, err := Packs()
+	dir, _ := ModsDir()
+	var dirs []string
+	for _, p := range packs {
+		if p.Enabled {
+			enabledPacks = append(enabledPacks, p.Name)
+			dirs = append(dirs, filepath.Join(dir, p.Name))
+		}
+	}
+	m := NewManager(dirs...)
+	if err != nil {
+		m.errs = append(m.errs, err)
+	}
+	return m
+}
@@ -0,0 +1,170 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	spritesPath    = "data/sprites.json"
+	spritesVersion = 1
+
+	LoopOnce = "once"
+	LoopLoop = "loop"
+)
+
+// Pivot is the point of a frame an animation turns around, as fractions of
+// the frame size. {0.5, 0.5} is the center.
+type Pivot struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// sheetDef describes a spritesheet of the manifest. Frames are read left to
+// right and top to bottom, Frames defaults to every whole frame of the image.
+type sheetDef struct {
+	Path        string    `json:"path"`
+	FrameWidth  int       `json:"frameWidth"`
+	FrameHeight int       `json:"frameHeight"`
+	Frames      int       `json:"frames"`
+	FPS         float64   `json:"fps"`
+	Pivot       *Pivot    `json:"pivot"`
+	Loop        string    `json:"loop"`
+	Clips       []clipDef `json:"clips"`
+}
+
+// clipDef is a run of frames of a sheet. Zero values take the sheet's.
+type clipDef struct {
+	Name   string  `json:"name"`
+	Start  int     `json:"start"`
+	Frames int     `json:"frames"`
+	FPS    float64 `json:"fps"`
+	Pivot  *Pivot  `json:"pivot"`
+	Loop   string  `json:"loop"`
+}
+
+// Clip is an animation ready to be played.
+type Clip struct {
+	Name        string
+	Sheet       *ebiten.Image
+	FrameWidth  int
+	FrameHeight int
+	Start       int
+	Frames      int
+	FPS         float64
+	Pivot       Pivot
+	Loop        bool
+}
+
+var clips = sync.OnceValue(func() map[string]*Clip {
+	var c map[string]*Clip
+	err := LoadData(spritesPath, func(data []byte) error {
+		var err error
+		c, err = readSprites(data)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+	return c
+})
+
+// FindClip returns the clip with the name, or nil if the manifest has none.
+func FindClip(name string) *Clip {
+	return clips()[name]
+}
+
+// readSprites decodes the spritesheet manifest and checks every sheet
+// against its image, reporting every problem at once.
+func readSprites(data []byte) (map[string]*Clip, error) {
+	var file struct {
+		Version int        `json:"version"`
+		Sheets  []sheetDef `json:"sheets"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("sprites: %w", err)
+	}
+	if file.Version != spritesVersion {
+		return nil, fmt.Errorf("sprites: unsupported version %d", file.Version)
+	}
+
+	clips := map[string]*Clip{}
+	var errs []error
+	for i, s := range file.Sheets {
+		what := fmt.Sprintf("sheets[%d] %s", i, s.Path)
+		check := func(ok bool, format string, args ...any) bool {
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: %s", what, fmt.Sprintf(format, args...)))
+			}
+			return ok
+		}
+
+		img, err := LoadImage(s.Path)
+		if !check(err == nil, "%v", err) {
+			continue
+		}
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		if !check(s.FrameWidth > 0 && s.FrameHeight > 0, "frame size must be positive") ||
+			!check(s.FrameWidth <= w && s.FrameHeight <= h, "frame %dx%d is larger than the %dx%d image", s.FrameWidth, s.FrameHeight, w, h) {
+			continue
+		}
+		available := (w / s.FrameWidth) * (h / s.FrameHeight)
+		if s.Frames == 0 {
+			s.Frames = available
+		}
+		check(s.Frames > 0 && s.Frames <= available, "frames must be between 1 and the %d frames of %dx%d in the %dx%d image, got %d", available, s.FrameWidth, s.FrameHeight, w, h, s.Frames)
+		check(s.FPS > 0, "fps must be positive")
+		if s.Loop == "" {
+			s.Loop = LoopOnce
+		}
+		check(s.Loop == LoopOnce || s.Loop == LoopLoop, "loop must be %q or %q, got %q", LoopOnce, LoopLoop, s.Loop)
+		if s.Pivot == nil {
+			s.Pivot = &Pivot{X: 0.5, Y: 0.5}
+		}
+		check(len(s.Clips) > 0, "at least one clip is needed")
+
+		for j, c := range s.Clips {
+			clip := &Clip{
+				Name:        c.Name,
+				Sheet:       img,
+				FrameWidth:  s.FrameWidth,
+				FrameHeight: s.FrameHeight,
+				Start:       c.Start,
+				Frames:      c.Frames,
+				FPS:         s.FPS,
+				Pivot:       *s.Pivot,
+				Loop:        s.Loop == LoopLoop,
+			}
+			if clip.Frames == 0 {
+				clip.Frames = s.Frames - c.Start
+			}
+			if c.FPS != 0 {
+				clip.FPS = c.FPS
+			}
+			if c.Pivot != nil {
+				clip.Pivot = *c.Pivot
+			}
+			if c.Loop != "" {
+				clip.Loop = c.Loop == LoopLoop
+			}
+			check(c.Name != "" && clips[c.Name] == nil, "clips[%d]: name must be set and unique", j)
+			check(c.Start >= 0 && clip.Frames > 0 && c.Start+clip.Frames <= s.Frames, "clips[%d] %q: frames %d to %d are not in the %d frames of the sheet", j, c.Name, c.Start, c.Start+clip.Frames-1, s.Frames)
+			check(clip.FPS > 0, "clips[%d] %q: fps must be positive", j, c.Name)
+			check(c.Loop == "" || c.Loop == LoopOnce || c.Loop == LoopLoop, "clips[%d] %q: loop must be %q or %q, got %q", j, c.Name, LoopOnce, LoopLoop, c.Loop)
+			clips[c.Name] = clip
+		}
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("sprites: %w", err)
+	}
+	return clips, nil
+}
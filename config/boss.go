@@ -0,0 +1,364 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"astrogame/assets"
+)
+
+const (
+	BossCatalogPath    = "data/bosses.json"
+	bossCatalogVersion = 1
+
+	AttackAimed  = "aimed"
+	AttackSpread = "spread"
+	AttackRadial = "radial"
+	AttackSweep  = "sweep"
+)
+
+// AttackPatterns are the ways a boss attack can fire its shots.
+var AttackPatterns = []string{AttackAimed, AttackSpread, AttackRadial, AttackSweep}
+
+// BossPartDef is a piece of a boss with its own HP. X and Y place its center
+// from the center of the core, in core sprite widths and heights.
+type BossPartDef struct {
+	Name   string  `json:"name"`
+	Sprite string  `json:"sprite"`
+	Scale  float64 `json:"scale"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	HP     int     `json:"hp"`
+	// Core is the part the boss dies with, every boss has exactly one
+	Core bool `json:"core"`
+	// WeakPoint passes the damage the part takes on to the core, multiplied
+	// by it. Zero keeps the damage to the part.
+	WeakPoint float64 `json:"weakPoint"`
+}
+
+// BossAttackDef fires Count shots of an enemy catalog weapon from a part.
+// Angle is in degrees: between two shots of aimed and spread attacks, the
+// width of a sweep, or how far a radial attack turns between two volleys.
+type BossAttackDef struct {
+	Name     string   `json:"name"`
+	Pattern  string   `json:"pattern"`
+	Part     string   `json:"part"`
+	Weapon   string   `json:"weapon"`
+	Count    int      `json:"count"`
+	Angle    float64  `json:"angle"`
+	Cooldown Duration `json:"cooldown"`
+}
+
+// BossPhaseDef starts when the core falls below the given share of its HP.
+// The first phase starts at 1. The attacks are fired in order and over
+// again, Speed is how fast the boss sways in pixels per tick.
+type BossPhaseDef struct {
+	Below   float64  `json:"below"`
+	Attacks []string `json:"attacks"`
+	Speed   float64  `json:"speed"`
+}
+
+type BossDef struct {
+	Name    string          `json:"name"`
+	Cost    int             `json:"cost"`
+	HPStep  float64         `json:"hpStep"`
+	Parts   []BossPartDef   `json:"parts"`
+	Attacks []BossAttackDef `json:"attacks"`
+	Phases  []BossPhaseDef  `json:"phases"`
+}
+
+// BossCatalog holds the bosses a level can end with. A level can spend
+// BudgetScale times the cost of its last wave on its boss.
+type BossCatalog struct {
+	Version     int       `json:"version"`
+	BudgetScale int       `json:"budgetScale"`
+	Bosses      []BossDef `json:"bosses"`
+
+	bosses []BossType
+}
+
+type BossType struct {
+	Name    string
+	Parts   []BossPart
+	Attacks []BossAttack
+	Phases  []BossPhase
+	cost    int
+	hpStep  float64
+}
+
+type BossPart struct {
+	Name      string
+	Sprite    *ebiten.Image
+	Scale     float64
+	Offset    Vector
+	HP        int
+	Core      bool
+	WeakPoint float64
+}
+
+type BossAttack struct {
+	Name     string
+	Pattern  string
+	Part     int
+	Weapon   *WeaponType
+	Count    int
+	Angle    float64
+	Cooldown time.Duration
+}
+
+type BossPhase struct {
+	Below   float64
+	Attacks []int
+	Speed   float64
+}
+
+var bossCatalog = mustLoadDefaultBossCatalog()
+
+func mustLoadDefaultBossCatalog() *BossCatalog {
+	var c *BossCatalog
+	err := assets.LoadData(BossCatalogPath, func(data []byte) error {
+		var err error
+		c, err = ReadBossCatalog(data)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ReadBossCatalog decodes and checks a boss catalog against the enemy
+// catalog, reporting every problem at once.
+func ReadBossCatalog(data []byte) (*BossCatalog, error) {
+	var c BossCatalog
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("boss catalog: %w", err)
+	}
+	if c.Version != bossCatalogVersion {
+		return nil, fmt.Errorf("boss catalog: unsupported version %d", c.Version)
+	}
+	err = c.resolve()
+	if err != nil {
+		return nil, fmt.Errorf("boss catalog: %w", err)
+	}
+	return &c, nil
+}
+
+func (c *BossCatalog) resolve() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(c.BudgetScale > 0, "budgetScale must be positive, got %d", c.BudgetScale)
+	check(len(c.Bosses) > 0, "bosses: at least one boss is needed")
+	names := map[string]bool{}
+	c.bosses = nil
+	for i, b := range c.Bosses {
+		what := fmt.Sprintf("bosses[%d] %q", i, b.Name)
+		check(b.Name != "" && !names[b.Name], "%s: name must be set and unique", what)
+		names[b.Name] = true
+		check(b.Cost >= 0, "%s: cost must not be negative", what)
+		check(b.HPStep >= 0, "%s: hpStep must not be negative", what)
+		boss := BossType{Name: b.Name, cost: b.Cost, hpStep: b.HPStep}
+
+		cores := 0
+		for j, p := range b.Parts {
+			what := fmt.Sprintf("%s: parts[%d] %q", what, j, p.Name)
+			check(p.Name != "" && !slices.ContainsFunc(b.Parts[:j], func(o BossPartDef) bool { return o.Name == p.Name }), "%s: name must be set and unique", what)
+			check(p.HP > 0, "%s: hp must be positive, got %d", what, p.HP)
+			check(p.WeakPoint >= 0, "%s: weakPoint must not be negative", what)
+			check(!p.Core || p.WeakPoint == 0, "%s: the core can't be a weak point", what)
+			if p.Scale == 0 {
+				p.Scale = 1
+			}
+			check(p.Scale > 0, "%s: scale must be positive", what)
+			part := BossPart{
+				Name:      p.Name,
+				Scale:     p.Scale,
+				Offset:    Vector{X: p.X, Y: p.Y},
+				HP:        p.HP,
+				Core:      p.Core,
+				WeakPoint: p.WeakPoint,
+			}
+			img, err := assets.LoadImage(p.Sprite)
+			check(err == nil, "%s: sprite: %v", what, err)
+			part.Sprite = img
+			if p.Core {
+				cores++
+			}
+			boss.Parts = append(boss.Parts, part)
+		}
+		check(cores == 1, "%s: exactly one part must be the core, got %d", what, cores)
+
+		for j, a := range b.Attacks {
+			what := fmt.Sprintf("%s: attacks[%d] %q", what, j, a.Name)
+			check(a.Name != "" && !slices.ContainsFunc(b.Attacks[:j], func(o BossAttackDef) bool { return o.Name == a.Name }), "%s: name must be set and unique", what)
+			check(slices.Contains(AttackPatterns, a.Pattern), "%s: pattern must be one of %q, got %q", what, AttackPatterns, a.Pattern)
+			part := slices.IndexFunc(b.Parts, func(p BossPartDef) bool { return p.Name == a.Part })
+			check(part >= 0, "%s: no part %q", what, a.Part)
+			weapon := enemyCatalog.weapon(a.Weapon)
+			check(weapon != nil, "%s: no weapon %q in the enemy catalog", what, a.Weapon)
+			check(a.Count > 0, "%s: count must be positive, got %d", what, a.Count)
+			check(a.Angle >= 0, "%s: angle must not be negative", what)
+			check(a.Cooldown > 0, "%s: cooldown must be positive", what)
+			boss.Attacks = append(boss.Attacks, BossAttack{
+				Name:     a.Name,
+				Pattern:  a.Pattern,
+				Part:     part,
+				Weapon:   weapon,
+				Count:    a.Count,
+				Angle:    a.Angle,
+				Cooldown: time.Duration(a.Cooldown),
+			})
+		}
+
+		check(len(b.Phases) > 0, "%s: at least one phase is needed", what)
+		for j, ph := range b.Phases {
+			what := fmt.Sprintf("%s: phases[%d]", what, j)
+			if j == 0 {
+				check(ph.Below == 1, "%s: below must be 1 for the first phase, got %v", what, ph.Below)
+			} else {
+				check(ph.Below > 0 && ph.Below < b.Phases[j-1].Below, "%s: below must be positive and lower than the one of the phase before, got %v", what, ph.Below)
+			}
+			check(len(ph.Attacks) > 0, "%s: at least one attack is needed", what)
+			check(ph.Speed >= 0, "%s: speed must not be negative", what)
+			phase := BossPhase{Below: ph.Below, Speed: ph.Speed}
+			for _, name := range ph.Attacks {
+				a := slices.IndexFunc(b.Attacks, func(a BossAttackDef) bool { return a.Name == name })
+				check(a >= 0, "%s: no attack %q", what, name)
+				phase.Attacks = append(phase.Attacks, a)
+			}
+			boss.Phases = append(boss.Phases, phase)
+		}
+		c.bosses = append(c.bosses, boss)
+	}
+	return errors.Join(errs...)
+}
+
+// SetBossCatalog makes the generator use c for the levels generated from now
+// on.
+func SetBossCatalog(c *BossCatalog) {
+	bossCatalog = c
+}
+
+// BossNames lists the bosses of the boss catalog in catalog order.
+func BossNames() []string {
+	var names []string
+	for _, b := range bossCatalog.Bosses {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// BossBudget is what the boss of a level whose last wave costs waveCost may
+// spend.
+func BossBudget(waveCost int) int {
+	return waveCost * bossCatalog.BudgetScale
+}
+
+// NewBoss picks one of the bosses the budget affords, the cheapest one when it
+// affords none, and spends the rest of the budget on it.
+func NewBoss(r *rand.Rand, budget int) *BossType {
+	var affordable []int
+	cheapest := 0
+	for i, b := range bossCatalog.bosses {
+		if b.cost <= budget {
+			affordable = append(affordable, i)
+		}
+		if b.cost < bossCatalog.bosses[cheapest].cost {
+			cheapest = i
+		}
+	}
+	i := cheapest
+	if len(affordable) > 0 {
+		i = affordable[r.Intn(len(affordable))]
+	}
+	b := bossCatalog.bosses[i].copy()
+	b.spend(budget - b.cost)
+	return b
+}
+
+// BossByName returns the named boss with the budget spent on it, or nil.
+func BossByName(name string, budget int) *BossType {
+	for _, b := range bossCatalog.bosses {
+		if b.Name == name {
+			boss := b.copy()
+			boss.spend(budget - boss.cost)
+			return boss
+		}
+	}
+	return nil
+}
+
+// copy returns a boss the generator may upgrade.
+func (b BossType) copy() *BossType {
+	b.Parts = slices.Clone(b.Parts)
+	b.Attacks = slices.Clone(b.Attacks)
+	for i := range b.Attacks {
+		w := *b.Attacks[i].Weapon
+		b.Attacks[i].Weapon = &w
+	}
+	return &b
+}
+
+// spend buys upgrades with the prices of the enemy economy, the way
+// DecorateEnemyTemplate does for enemies. HP upgrades add HPStep of their
+// base HP to every part.
+func (b *BossType) spend(cost int) {
+	economy := enemyCatalog.Economy
+	base := make([]int, len(b.Parts))
+	for i, p := range b.Parts {
+		base[i] = p.HP
+	}
+	for cost > 0 {
+		if cost >= economy.HPCost {
+			cost -= economy.HPCost
+			for i := range b.Parts {
+				b.Parts[i].HP += int(math.Ceil(float64(base[i]) * b.hpStep))
+			}
+		}
+		if cost >= economy.DamageCost {
+			cost -= economy.DamageCost
+			for _, a := range b.Attacks {
+				a.Weapon.Damage++
+			}
+		}
+		if cost >= economy.FireRateCost {
+			cost -= economy.FireRateCost
+			for i := range b.Attacks {
+				a := &b.Attacks[i]
+				a.Cooldown = max(a.Cooldown+time.Duration(economy.FireRateStep), time.Duration(economy.MinFireInterval))
+			}
+		}
+		if cost >= economy.ProjectileVelocityCost {
+			cost -= economy.ProjectileVelocityCost
+			for _, a := range b.Attacks {
+				step := economy.ProjectileVelocityStep
+				if a.Weapon.TargetType == TargetTypePlayer {
+					step = economy.GuidedProjectileVelocityStep
+				}
+				a.Weapon.Velocity += step
+				a.Weapon.StartVelocity += step
+			}
+		}
+		cost--
+	}
+}
+
+// Core returns the index of the core part.
+func (b *BossType) Core() int {
+	return slices.IndexFunc(b.Parts, func(p BossPart) bool { return p.Core })
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"astrogame/assets"
+)
+
+const (
+	EnemyCatalogPath    = "data/enemies.json"
+	enemyCatalogVersion = 2
+)
+
+// Duration is a time.Duration written as a string with a unit in data files,
+// like "1400ms" or "4s". Bare numbers are refused so that nobody writes
+// nanoseconds by accident.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string with a unit like \"1400ms\", got %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// WaveCost is what every enemy of a wave may spend, bosses get a multiple of
+// the cost of the last wave of their level.
+func WaveCost(l, s, w int) int {
+	costLvlIdx := l + 1
+	costStageIdx := s + 1
+	costWaveIdx := w + 1
+	return costLvlIdx*20 + 10*costStageIdx + costWaveIdx*2
+}
+
+// EnemyEconomy prices what DecorateEnemyTemplate buys for an enemy once its
+// body and weapon are paid. FireRateStep is added to the fire interval with
+// every fire rate upgrade, a negative step fires faster down to
+// MinFireInterval.
+type EnemyEconomy struct {
+	HPCost                       int      `json:"hpCost"`
+	VelocityCost                 int      `json:"velocityCost"`
+	VelocityStep                 float64  `json:"velocityStep"`
+	ProjectileVelocityCost       int      `json:"projectileVelocityCost"`
+	ProjectileVelocityStep       float64  `json:"projectileVelocityStep"`
+	GuidedProjectileVelocityStep float64  `json:"guidedProjectileVelocityStep"`
+	FireRateCost                 int      `json:"fireRateCost"`
+	FireRateStep                 Duration `json:"fireRateStep"`
+	MinFireInterval              Duration `json:"minFireInterval"`
+	DamageCost                   int      `json:"damageCost"`
+	AmmoCost                     int      `json:"ammoCost"`
+	WeaponMinCost                int      `json:"weaponMinCost"`
+}
+
+type EnemyBodyDef struct {
+	Name      string   `json:"name"`
+	Cost      int      `json:"cost"`
+	Sprite    string   `json:"sprite"`
+	Velocity  float64  `json:"velocity"`
+	HP        int      `json:"hp"`
+	Target    string   `json:"target"`
+	SpawnTime Duration `json:"spawnTime"`
+}
+
+type EnemyWeaponDef struct {
+	Name   string `json:"name"`
+	Cost   int    `json:"cost"`
+	Sprite string `json:"sprite"`
+	// Blow names a clip of the spritesheet manifest
+	Blow         string   `json:"blow"`
+	Velocity     float64  `json:"velocity"`
+	Damage       int      `json:"damage"`
+	Target       string   `json:"target"`
+	FireInterval Duration `json:"fireInterval"`
+	Ammo         int      `json:"ammo"`
+}
+
+// EnemyCatalog is the roster the level generator builds enemies from.
+type EnemyCatalog struct {
+	Version int              `json:"version"`
+	Economy EnemyEconomy     `json:"economy"`
+	Bodies  []EnemyBodyDef   `json:"bodies"`
+	Weapons []EnemyWeaponDef `json:"weapons"`
+
+	bodies  []EnemyBody
+	weapons []WeaponType
+}
+
+var enemyCatalog = mustLoadDefaultEnemyCatalog()
+
+func mustLoadDefaultEnemyCatalog() *EnemyCatalog {
+	var c *EnemyCatalog
+	err := assets.LoadData(EnemyCatalogPath, func(data []byte) error {
+		var err error
+		c, err = ReadEnemyCatalog(data)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ReadEnemyCatalog decodes and checks an enemy catalog. Unknown fields are
+// errors so that typos don't silently fall back to zero, and every problem
+// found is reported at once.
+func ReadEnemyCatalog(data []byte) (*EnemyCatalog, error) {
+	var c EnemyCatalog
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("enemy catalog: %w", err)
+	}
+	if c.Version != enemyCatalogVersion {
+		return nil, fmt.Errorf("enemy catalog: unsupported version %d", c.Version)
+	}
+	err = c.build()
+	if err != nil {
+		return nil, fmt.Errorf("enemy catalog: %w", err)
+	}
+	return &c, nil
+}
+
+func (c *EnemyCatalog) build() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	sprite := func(what, path string) *ebiten.Image {
+		if path == "" {
+			errs = append(errs, fmt.Errorf("%s: missing", what))
+			return nil
+		}
+		img, err := assets.LoadImage(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", what, err))
+		}
+		return img
+	}
+	target := func(what, t string) {
+		check(t == TargetTypeStraight || t == TargetTypePlayer, "%s: target must be %q or %q, got %q", what, TargetTypeStraight, TargetTypePlayer, t)
+	}
+
+	e := c.Economy
+	for _, f := range []struct {
+		name string
+		cost int
+	}{
+		{"hpCost", e.HPCost},
+		{"velocityCost", e.VelocityCost},
+		{"projectileVelocityCost", e.ProjectileVelocityCost},
+		{"fireRateCost", e.FireRateCost},
+		{"damageCost", e.DamageCost},
+		{"ammoCost", e.AmmoCost},
+		{"weaponMinCost", e.WeaponMinCost},
+	} {
+		check(f.cost > 0, "economy.%s must be positive, got %d", f.name, f.cost)
+	}
+	check(e.VelocityStep >= 0 && e.ProjectileVelocityStep >= 0 && e.GuidedProjectileVelocityStep >= 0, "economy: velocity steps must not be negative")
+	check(e.MinFireInterval > 0, "economy.minFireInterval must be positive")
+
+	check(len(c.Bodies) > 0, "bodies: at least one body is needed")
+	check(len(c.Weapons) > 0, "weapons: at least one weapon is needed")
+	names := map[string]bool{}
+	c.bodies = nil
+	for i, b := range c.Bodies {
+		what := fmt.Sprintf("bodies[%d] %q", i, b.Name)
+		check(b.Name != "" && !names[b.Name], "%s: name must be set and unique", what)
+		names[b.Name] = true
+		check(b.Cost > 0, "%s: cost must be positive, got %d", what, b.Cost)
+		check(b.Velocity > 0, "%s: velocity must be positive, got %v", what, b.Velocity)
+		check(b.HP > 0, "%s: hp must be positive, got %d", what, b.HP)
+		check(b.SpawnTime > 0, "%s: spawnTime must be positive", what)
+		target(what, b.Target)
+		c.bodies = append(c.bodies, EnemyBody{
+			cost:           b.Cost,
+			sprite:         sprite(what+": sprite", b.Sprite),
+			velocity:       b.Velocity,
+			startHP:        b.HP,
+			targetType:     b.Target,
+			enemySpawnTime: time.Duration(b.SpawnTime),
+		})
+	}
+	if len(c.Bodies) > 0 {
+		cheapest := slices.MinFunc(c.Bodies, func(a, b EnemyBodyDef) int { return a.Cost - b.Cost })
+		check(cheapest.Cost <= WaveCost(0, 0, 0), "bodies: the cheapest body %q costs %d, more than the %d an enemy of the first wave can spend", cheapest.Name, cheapest.Cost, WaveCost(0, 0, 0))
+	}
+	names = map[string]bool{}
+	c.weapons = nil
+	for i, w := range c.Weapons {
+		what := fmt.Sprintf("weapons[%d] %q", i, w.Name)
+		check(w.Name != "" && !names[w.Name], "%s: name must be set and unique", what)
+		names[w.Name] = true
+		check(w.Cost > 0, "%s: cost must be positive, got %d", what, w.Cost)
+		check(w.Velocity > 0, "%s: velocity must be positive, got %v", what, w.Velocity)
+		check(w.Damage > 0, "%s: damage must be positive, got %d", what, w.Damage)
+		check(w.Ammo > 0, "%s: ammo must be positive, got %d", what, w.Ammo)
+		check(w.FireInterval >= e.MinFireInterval, "%s: fireInterval %v is below economy.minFireInterval %v", what, time.Duration(w.FireInterval), time.Duration(e.MinFireInterval))
+		target(what, w.Target)
+		check(w.Blow == "" || assets.FindClip(w.Blow) != nil, "%s: blow: no clip %q in the spritesheet manifest", what, w.Blow)
+		c.weapons = append(c.weapons, WeaponType{
+			cost:          w.Cost,
+			Sprite:        sprite(what+": sprite", w.Sprite),
+			BlowClip:      w.Blow,
+			Velocity:      w.Velocity,
+			StartVelocity: w.Velocity,
+			Damage:        w.Damage,
+			TargetType:    w.Target,
+			StartTime:     time.Duration(w.FireInterval),
+			StartAmmo:     w.Ammo,
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// SetEnemyCatalog makes the generator use c for the levels generated from now
+// on.
+func SetEnemyCatalog(c *EnemyCatalog) {
+	enemyCatalog = c
+}
+
+// body returns a fresh copy of the named body, or nil.
+func (c *EnemyCatalog) body(name string) *EnemyBody {
+	for i, b := range c.Bodies {
+		if b.Name == name {
+			body := c.bodies[i]
+			return &body
+		}
+	}
+	return nil
+}
+
+// weapon returns a fresh copy of the named weapon, or nil.
+func (c *EnemyCatalog) weapon(name string) *WeaponType {
+	for i, w := range c.Weapons {
+		if w.Name == name {
+			weapon := c.weapons[i]
+			return &weapon
+		}
+	}
+	return nil
+}
+
+// EnemyBodyNames lists the bodies of the enemy catalog in catalog order.
+func EnemyBodyNames() []string {
+	var names []string
+	for _, b := range enemyCatalog.Bodies {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// EnemyWeaponNames lists the weapons of the enemy catalog in catalog order.
+func EnemyWeaponNames() []string {
+	var names []string
+	for _, w := range enemyCatalog.Weapons {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+func Economy() EnemyEconomy {
+	return enemyCatalog.Economy
+}
@@ -52,16 +52,6 @@ const (
 	PentaLaser          = "pentaLaser"
 )
 
-type GameState string
-
-var (
-	MainMenu           GameState = "mainMenu"
-	InGame             GameState = "inGame"
-	Profile            GameState = "profile"
-	Options            GameState = "options"
-	ShipChoosingWindow GameState = "shipChoosing"
-)
-
 type ItemTemplate struct {
 	Sprite           *ebiten.Image
 	Velocity         float64
@@ -102,6 +92,8 @@ type Level struct {
 	Name     string
 	Number   int
 	LevelId  int
+	// Boss has to be beaten after the last stage, nil when the level has none
+	Boss *BossType
 }
 
 type Stage struct {
@@ -123,24 +115,30 @@ type EnemyBatch struct {
 	BatchSpawnTime    time.Duration
 	Count             int
 	StartPosOffset    float64
+	// Movement names how the enemies of the batch fly, one of Movements
+	Movement string
 }
 
 type WeaponType struct {
-	Sprite                        *ebiten.Image
-	ItemSprite                    *ebiten.Image
-	IntercectAnimationSpriteSheet *ebiten.Image
-	InstantAnimationSpiteSheet    *ebiten.Image
-	Scale                         float64
-	AnimationOnly                 bool
-	Velocity                      float64
-	StartVelocity                 float64
-	Damage                        int
-	Target                        Vector
-	TargetType                    string
-	WeaponName                    string
-	cost                          int
-	StartTime                     time.Duration
-	StartAmmo                     int
+	Sprite     *ebiten.Image
+	ItemSprite *ebiten.Image
+	// BlowClip and TrailClip name clips of the spritesheet manifest
+	BlowClip      string
+	TrailClip     string
+	Scale         float64
+	AnimationOnly bool
+	Velocity      float64
+	StartVelocity float64
+	Damage        int
+	Target        Vector
+	TargetType    string
+	WeaponName    string
+	cost          int
+	StartTime     time.Duration
+	StartAmmo     int
+	// BlastRadius is the width of the blow of a hit in projectile widths,
+	// zero for projectiles that only damage what they hit
+	BlastRadius float64
 }
 
 type AmmoType struct {
@@ -194,16 +192,51 @@ type LevelTemplate struct {
 	Stages []*StageTemplate
 	BgImg  *ebiten.Image
 	Name   string
+	Boss   *BossType
+}
+
+const (
+	FormationCentered  = "centered"
+	FormationLines     = "lines"
+	FormationCheckmate = "checkmate"
+	FormationWedge     = "wedge"
+	FormationArrowhead = "arrowhead"
+	FormationCircle    = "circle"
+	FormationSpiral    = "spiral"
+	FormationFlanks    = "flanks"
+	FormationColumns   = "columns"
+	FormationSurround  = "surround"
+)
+
+// Formations are the start position types a batch can use.
+var Formations = []string{
+	FormationCentered, FormationLines, FormationCheckmate, FormationWedge, FormationArrowhead,
+	FormationCircle, FormationSpiral, FormationFlanks, FormationColumns, FormationSurround,
 }
 
-var startPosTypes = []string{"centered", "lines", "checkmate"}
+const (
+	MovementStraight = "straight"
+	MovementSine     = "sine"
+	MovementZigzag   = "zigzag"
+	MovementCircle   = "circle"
+	MovementDive     = "dive"
+	MovementBezier   = "bezier"
+	MovementHover    = "hover"
+	MovementFollow   = "follow"
+)
+
+// Movements are the ways the enemies of a batch can fly. Straight keeps the
+// straight or homing flight of the target type, the others script the flight
+// and leave the target type to the weapon.
+var Movements = []string{MovementStraight, MovementSine, MovementZigzag, MovementCircle, MovementDive, MovementBezier, MovementHover, MovementFollow}
 
-func (l *LevelTemplate) ToLevel() *Level {
+func (l *LevelTemplate) ToLevel(r *rand.Rand) *Level {
 	var stages []Stage
 	var level *Level = &Level{
 		Stages: stages,
 		BgImg:  l.BgImg,
 		Name:   l.Name,
+		Boss:   l.Boss,
 	}
 	for s := range l.Stages {
 		level.Stages = append(level.Stages, Stage{StageId: s})
@@ -224,16 +257,17 @@ func (l *LevelTemplate) ToLevel() *Level {
 	for s, stage := range level.Stages {
 		for w := range stage.Waves {
 			for _, batch := range l.Stages[s].Waves[w].Batches {
-				randPosType := startPosTypes[rand.Intn(len(startPosTypes))]
+				randPosType := Formations[r.Intn(len(Formations))]
 				enemyCount := len(batch.Enemies) * 10
 				if batch.Enemies[0].TargetType == TargetTypePlayer {
 					enemyCount = len(batch.Enemies) * 6
 				}
 				startPosOffset := batch.Enemies[0].Sprite.Bounds().Dx() / 2
-				if enemyCount*(startPosOffset+startPosOffset)-int(startPosOffset) <= ScreenWidth1024X768 {
-					randPosType = "centered"
-				} else if randPosType == "centered" && enemyCount*(startPosOffset+startPosOffset)-int(startPosOffset) > ScreenWidth1024X768 {
-					randPosType = "checkmate"
+				gridFormation := randPosType == FormationCentered || randPosType == FormationLines || randPosType == FormationCheckmate
+				if gridFormation && enemyCount*(startPosOffset+startPosOffset)-int(startPosOffset) <= ScreenWidth1024X768 {
+					randPosType = FormationCentered
+				} else if randPosType == FormationCentered && enemyCount*(startPosOffset+startPosOffset)-int(startPosOffset) > ScreenWidth1024X768 {
+					randPosType = FormationCheckmate
 				}
 				stage.Waves[w].Batches = append(level.Stages[s].Waves[w].Batches, EnemyBatch{
 					Type:              batch.Enemies[0].ToEnemy(),
@@ -242,6 +276,7 @@ func (l *LevelTemplate) ToLevel() *Level {
 					BatchSpawnTime:    batch.Enemies[0].EnemySpawnTime,
 					Count:             enemyCount,
 					StartPosOffset:    float64(startPosOffset),
+					Movement:          Movements[r.Intn(len(Movements))],
 				})
 			}
 		}
@@ -302,45 +337,45 @@ func (e *EnemyTemplate) SetWeapon(w *WeaponType) {
 }
 
 func (e *EnemyTemplate) AddHP() {
-	if e.CurCost >= 200 {
-		e.CurCost -= 200
+	if e.CurCost >= enemyCatalog.Economy.HPCost {
+		e.CurCost -= enemyCatalog.Economy.HPCost
 		e.StartHP++
 	}
 }
 
 func (e *EnemyTemplate) AddVelocity() {
-	if e.CurCost >= 12 {
-		e.CurCost -= 12
-		e.Velocity += 0.1
+	if e.CurCost >= enemyCatalog.Economy.VelocityCost {
+		e.CurCost -= enemyCatalog.Economy.VelocityCost
+		e.Velocity += enemyCatalog.Economy.VelocityStep
 	}
 }
 
 func (e *EnemyTemplate) AddWeaponProjectileVelocity(velocity float64) {
-	if e.CurCost >= 100 && e.WeaponType != nil {
-		e.CurCost -= 100
+	if e.CurCost >= enemyCatalog.Economy.ProjectileVelocityCost && e.WeaponType != nil {
+		e.CurCost -= enemyCatalog.Economy.ProjectileVelocityCost
 		e.WeaponType.Velocity += velocity
 		e.WeaponType.StartVelocity += velocity
-
 	}
 }
 
 func (e *EnemyTemplate) AddWeaponProjectileFireRate() {
-	if e.CurCost >= 200 && e.WeaponType != nil {
-		e.CurCost -= 200
-		e.WeaponType.StartTime++
+	economy := enemyCatalog.Economy
+	if e.CurCost >= economy.FireRateCost && e.WeaponType != nil {
+		e.CurCost -= economy.FireRateCost
+		e.WeaponType.StartTime = max(e.WeaponType.StartTime+time.Duration(economy.FireRateStep), time.Duration(economy.MinFireInterval))
 	}
 }
 
 func (e *EnemyTemplate) AddWeaponProjectileDamage() {
-	if e.CurCost >= 140 && e.WeaponType != nil {
-		e.CurCost -= 140
+	if e.CurCost >= enemyCatalog.Economy.DamageCost && e.WeaponType != nil {
+		e.CurCost -= enemyCatalog.Economy.DamageCost
 		e.WeaponType.Damage++
 	}
 }
 
 func (e *EnemyTemplate) AddWeaponAmmo() {
-	if e.CurCost >= 1 && e.WeaponType != nil {
-		e.CurCost--
+	if e.CurCost >= enemyCatalog.Economy.AmmoCost && e.WeaponType != nil {
+		e.CurCost -= enemyCatalog.Economy.AmmoCost
 		e.WeaponType.StartAmmo++
 	}
 }
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"astrogame/assets"
+	"astrogame/objects"
+)
+
+const levelFileVersion = 1
+
+const (
+	ItemKindWeapon    = "weapon"
+	ItemKindSecondary = "secondary"
+	ItemKindAmmo      = "ammo"
+	ItemKindHeal      = "heal"
+	ItemKindShield    = "shield"
+)
+
+// LevelFile is a hand-authored level. It follows LevelTemplate, StageTemplate,
+// WaveTemplate and BatchTemplate, but everything the generator would roll is
+// written out.
+type LevelFile struct {
+	Version    int         `json:"version"`
+	Name       string      `json:"name"`
+	Background string      `json:"background,omitempty"`
+	Stages     []StageFile `json:"stages"`
+	Boss       *BossFile   `json:"boss,omitempty"`
+}
+
+// BossFile ends the level with a boss of the boss catalog, Budget is spent on
+// upgrading it the way the generator does.
+type BossFile struct {
+	Name   string `json:"name"`
+	Budget int    `json:"budget,omitempty"`
+}
+
+type StageFile struct {
+	Meteors int        `json:"meteors,omitempty"`
+	Items   []ItemFile `json:"items,omitempty"`
+	Waves   []WaveFile `json:"waves"`
+}
+
+type WaveFile struct {
+	Batches []BatchFile `json:"batches"`
+}
+
+// BatchFile spawns Count copies of a catalog body carrying a catalog weapon.
+// The zero values of the optional fields keep the catalog's numbers, an
+// empty formation picks "centered" when the batch fits in one line and
+// "checkmate" otherwise, and an empty movement flies straight.
+type BatchFile struct {
+	Body      string   `json:"body"`
+	Weapon    string   `json:"weapon"`
+	Count     int      `json:"count"`
+	Formation string   `json:"formation,omitempty"`
+	SpawnTime Duration `json:"spawnTime,omitempty"`
+	HP        int      `json:"hp,omitempty"`
+	Velocity  float64  `json:"velocity,omitempty"`
+	Target    string   `json:"target,omitempty"`
+	Movement  string   `json:"movement,omitempty"`
+}
+
+type ItemFile struct {
+	Kind      string    `json:"kind"`
+	Weapon    string    `json:"weapon,omitempty"`
+	Amount    int       `json:"amount,omitempty"`
+	HP        int       `json:"hp,omitempty"`
+	Sprite    SpriteRef `json:"sprite"`
+	Velocity  float64   `json:"velocity"`
+	SpawnTime Duration  `json:"spawnTime"`
+}
+
+type SpriteRef struct {
+	Path  string  `json:"path"`
+	Scale float64 `json:"scale,omitempty"`
+}
+
+// NewLevelFile returns an empty level of the current version.
+func NewLevelFile(name string) *LevelFile {
+	return &LevelFile{Version: levelFileVersion, Name: name}
+}
+
+// ReadLevelFile decodes a level file and checks it against the current enemy
+// catalog.
+func ReadLevelFile(data []byte) (*LevelFile, error) {
+	f, err := DecodeLevelFile(data)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.ToLevel()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// DecodeLevelFile only decodes a level file, so that unfinished levels can
+// still be opened in the editor.
+func DecodeLevelFile(data []byte) (*LevelFile, error) {
+	var f LevelFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&f)
+	if err != nil {
+		return nil, err
+	}
+	if f.Version != levelFileVersion {
+		return nil, fmt.Errorf("unsupported version %d", f.Version)
+	}
+	return &f, nil
+}
+
+// ToLevel builds a fresh level from the file. Background is left nil when the
+// file names none.
+func (f *LevelFile) ToLevel() (*Level, error) {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	sprite := func(what string, ref SpriteRef) *ebiten.Image {
+		if ref.Path == "" {
+			errs = append(errs, fmt.Errorf("%s: missing", what))
+			return nil
+		}
+		img, err := assets.LoadImage(ref.Path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", what, err))
+			return nil
+		}
+		if ref.Scale != 0 && ref.Scale != 1 {
+			img = objects.ScaleImg(img, ref.Scale)
+		}
+		return img
+	}
+
+	level := &Level{Name: f.Name}
+	check(f.Name != "", "name must be set")
+	if f.Background != "" {
+		level.BgImg = sprite("background", SpriteRef{Path: f.Background})
+	}
+	check(len(f.Stages) > 0, "stages: at least one stage is needed")
+	for s, sf := range f.Stages {
+		stage := Stage{StageId: s, MeteorsCount: sf.Meteors}
+		check(sf.Meteors >= 0, "stages[%d]: meteors must not be negative", s)
+		check(len(sf.Waves) > 0, "stages[%d]: at least one wave is needed", s)
+		for i, it := range sf.Items {
+			what := fmt.Sprintf("stages[%d].items[%d]", s, i)
+			item := Item{
+				Sprite:        sprite(what+": sprite", it.Sprite),
+				Velocity:      it.Velocity,
+				ItemSpawnTime: time.Duration(it.SpawnTime),
+			}
+			check(it.Velocity > 0, "%s: velocity must be positive, got %v", what, it.Velocity)
+			check(it.SpawnTime > 0, "%s: spawnTime must be positive", what)
+			switch it.Kind {
+			case ItemKindWeapon:
+				check(it.Weapon != "", "%s: weapon must be set", what)
+				item.WeaponType = &WeaponType{WeaponName: it.Weapon}
+			case ItemKindSecondary:
+				check(it.Weapon != "", "%s: weapon must be set", what)
+				item.SecondWeaponType = &WeaponType{WeaponName: it.Weapon}
+			case ItemKindAmmo:
+				check(it.Weapon != "", "%s: weapon must be set", what)
+				check(it.Amount > 0, "%s: amount must be positive, got %d", what, it.Amount)
+				item.AmmoType = &AmmoType{WeaponName: it.Weapon, Amount: it.Amount}
+			case ItemKindHeal:
+				check(it.HP > 0, "%s: hp must be positive, got %d", what, it.HP)
+				item.HealType = &HealType{HP: it.HP}
+			case ItemKindShield:
+				check(it.HP > 0, "%s: hp must be positive, got %d", what, it.HP)
+				item.ShieldType = &ShieldType{HP: it.HP, Sprite: assets.ShieldSprite}
+			default:
+				errs = append(errs, fmt.Errorf("%s: unknown kind %q", what, it.Kind))
+			}
+			stage.Items = append(stage.Items, item)
+		}
+		for w, wf := range sf.Waves {
+			wave := Wave{WaveId: w}
+			check(len(wf.Batches) > 0, "stages[%d].waves[%d]: at least one batch is needed", s, w)
+			for b, bf := range wf.Batches {
+				what := fmt.Sprintf("stages[%d].waves[%d].batches[%d]", s, w, b)
+				batch, err := bf.ToBatch()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", what, err))
+					continue
+				}
+				wave.Batches = append(wave.Batches, batch)
+			}
+			stage.Waves = append(stage.Waves, wave)
+		}
+		level.Stages = append(level.Stages, stage)
+	}
+	if f.Boss != nil {
+		check(f.Boss.Budget >= 0, "boss: budget must not be negative, got %d", f.Boss.Budget)
+		level.Boss = BossByName(f.Boss.Name, f.Boss.Budget)
+		check(level.Boss != nil, "boss: no boss %q in the boss catalog", f.Boss.Name)
+	}
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+// ToBatch resolves the batch against the enemy catalog.
+func (bf BatchFile) ToBatch() (EnemyBatch, error) {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	e := &EnemyTemplate{}
+	body := enemyCatalog.body(bf.Body)
+	weapon := enemyCatalog.weapon(bf.Weapon)
+	check(body != nil, "no body %q in the enemy catalog", bf.Body)
+	check(weapon != nil, "no weapon %q in the enemy catalog", bf.Weapon)
+	check(bf.Count > 0, "count must be positive, got %d", bf.Count)
+	check(bf.HP >= 0, "hp must not be negative, got %d", bf.HP)
+	check(bf.Velocity >= 0, "velocity must not be negative, got %v", bf.Velocity)
+	check(bf.SpawnTime >= 0, "spawnTime must not be negative")
+	check(bf.Target == "" || bf.Target == TargetTypeStraight || bf.Target == TargetTypePlayer,
+		"target must be %q or %q, got %q", TargetTypeStraight, TargetTypePlayer, bf.Target)
+	formation := bf.Formation
+	check(formation == "" || slices.Contains(Formations, formation), "formation must be one of %q, got %q", Formations, formation)
+	movement := bf.Movement
+	check(movement == "" || slices.Contains(Movements, movement), "movement must be one of %q, got %q", Movements, movement)
+	if movement == "" {
+		movement = MovementStraight
+	}
+	if body == nil || weapon == nil || len(errs) > 0 {
+		return EnemyBatch{}, errors.Join(errs...)
+	}
+
+	e.CurCost = body.cost + weapon.cost
+	e.SetBody(body)
+	e.SetWeapon(weapon)
+	if bf.HP > 0 {
+		e.StartHP = bf.HP
+	}
+	if bf.Velocity > 0 {
+		e.Velocity = bf.Velocity
+	}
+	if bf.SpawnTime > 0 {
+		e.EnemySpawnTime = time.Duration(bf.SpawnTime)
+	}
+	if bf.Target != "" {
+		e.TargetType = bf.Target
+	}
+	startPosOffset := e.Sprite.Bounds().Dx() / 2
+	if formation == "" {
+		formation = FormationCheckmate
+		if bf.Count*(startPosOffset+startPosOffset)-startPosOffset <= ScreenWidth1024X768 {
+			formation = FormationCentered
+		}
+	}
+	return EnemyBatch{
+		Type:              e.ToEnemy(),
+		TargetType:        e.TargetType,
+		StartPositionType: formation,
+		BatchSpawnTime:    e.EnemySpawnTime,
+		Count:             bf.Count,
+		StartPosOffset:    float64(startPosOffset),
+		Movement:          movement,
+	}, nil
+}
@@ -6,169 +6,24 @@ import (
 	"time"
 )
 
+// NewEnemyBodies returns fresh copies of the bodies of the enemy catalog, so
+// that the generator may upgrade them.
 func NewEnemyBodies() []*EnemyBody {
 	var enemyBodies []*EnemyBody
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           20,
-		sprite:         assets.Enemy1,
-		velocity:       1,
-		startHP:        1,
-		targetType:     TargetTypeStraight,
-		enemySpawnTime: 4 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           30,
-		sprite:         assets.Enemy2,
-		velocity:       1.2,
-		startHP:        3,
-		targetType:     TargetTypePlayer,
-		enemySpawnTime: 5 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           42,
-		sprite:         assets.Enemy3,
-		velocity:       1.5,
-		startHP:        3,
-		targetType:     TargetTypeStraight,
-		enemySpawnTime: 4 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           76,
-		sprite:         assets.Enemy4,
-		velocity:       1.4,
-		startHP:        6,
-		targetType:     TargetTypePlayer,
-		enemySpawnTime: 4 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           120,
-		sprite:         assets.Enemy5,
-		velocity:       1.8,
-		startHP:        4,
-		targetType:     TargetTypeStraight,
-		enemySpawnTime: 5 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           178,
-		sprite:         assets.Enemy6,
-		velocity:       1.2,
-		startHP:        7,
-		targetType:     TargetTypeStraight,
-		enemySpawnTime: 5 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           290,
-		sprite:         assets.Enemy7,
-		velocity:       2,
-		startHP:        5,
-		targetType:     TargetTypeStraight,
-		enemySpawnTime: 5 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           520,
-		sprite:         assets.Enemy8,
-		velocity:       1.3,
-		startHP:        8,
-		targetType:     TargetTypePlayer,
-		enemySpawnTime: 6 * time.Second,
-	})
-	enemyBodies = append(enemyBodies, &EnemyBody{
-		cost:           640,
-		sprite:         assets.Enemy9,
-		velocity:       1.5,
-		startHP:        12,
-		targetType:     TargetTypePlayer,
-		enemySpawnTime: 8 * time.Second,
-	})
+	for _, b := range enemyCatalog.bodies {
+		b := b
+		enemyBodies = append(enemyBodies, &b)
+	}
 	return enemyBodies
 }
 
+// NewWeaponTypes returns fresh copies of the weapons of the enemy catalog.
 func NewWeaponTypes() []*WeaponType {
 	var weaponTypes []*WeaponType
-	weaponTypes = append(weaponTypes, &WeaponType{
-		cost:                          6,
-		Sprite:                        assets.EnemyLightMissile,
-		IntercectAnimationSpriteSheet: assets.LightMissileBlowSpriteSheet,
-		Velocity:                      150,
-		StartVelocity:                 150,
-		Damage:                        2,
-		TargetType:                    TargetTypeStraight,
-		AnimationOnly:                 false,
-		StartTime:                     time.Duration(1400) * time.Millisecond,
-		StartAmmo:                     30,
-	})
-	weaponTypes = append(weaponTypes, &WeaponType{
-		cost:                          20,
-		Sprite:                        assets.EnemyAutoLightMissile,
-		IntercectAnimationSpriteSheet: assets.LightMissileBlowSpriteSheet,
-		Velocity:                      1.5,
-		StartVelocity:                 1.5,
-		Damage:                        2,
-		TargetType:                    TargetTypePlayer,
-		AnimationOnly:                 false,
-		StartTime:                     time.Duration(2000) * time.Millisecond,
-		StartAmmo:                     5,
-	})
-	weaponTypes = append(weaponTypes, &WeaponType{
-		cost:                          40,
-		Sprite:                        assets.EnemyHeavyMissile,
-		IntercectAnimationSpriteSheet: assets.LightMissileBlowSpriteSheet,
-		Velocity:                      130,
-		StartVelocity:                 130,
-		Damage:                        5,
-		TargetType:                    TargetTypeStraight,
-		AnimationOnly:                 false,
-		StartTime:                     time.Duration(1700)*time.Millisecond + 1700,
-		StartAmmo:                     10,
-	})
-	weaponTypes = append(weaponTypes, &WeaponType{
-		cost:                          70,
-		Sprite:                        assets.EnemyHeavyMissile,
-		IntercectAnimationSpriteSheet: assets.LightMissileBlowSpriteSheet,
-		Velocity:                      2.2,
-		StartVelocity:                 2.2,
-		Damage:                        5,
-		TargetType:                    TargetTypePlayer,
-		AnimationOnly:                 false,
-		StartTime:                     2600,
-		StartAmmo:                     5,
-	})
-	weaponTypes = append(weaponTypes, &WeaponType{
-		cost:                          56,
-		Sprite:                        assets.EnemyGunProjevtile,
-		IntercectAnimationSpriteSheet: assets.LightMissileBlowSpriteSheet,
-		Velocity:                      280,
-		StartVelocity:                 280,
-		Damage:                        3,
-		TargetType:                    TargetTypeStraight,
-		AnimationOnly:                 false,
-		StartTime:                     1600,
-		StartAmmo:                     16,
-	})
-	weaponTypes = append(weaponTypes, &WeaponType{
-		cost:                          86,
-		Sprite:                        assets.EnemyBullet,
-		IntercectAnimationSpriteSheet: assets.ProjectileBlowSpriteSheet,
-		Velocity:                      350,
-		StartVelocity:                 350,
-		Damage:                        1,
-		TargetType:                    TargetTypeStraight,
-		AnimationOnly:                 false,
-		StartTime:                     400,
-		StartAmmo:                     100,
-	})
-	weaponTypes = append(weaponTypes, &WeaponType{
-		cost:                          100,
-		Sprite:                        assets.EnemyMidMissile,
-		IntercectAnimationSpriteSheet: assets.LightMissileBlowSpriteSheet,
-		Velocity:                      2.4,
-		StartVelocity:                 2.4,
-		Damage:                        4,
-		TargetType:                    TargetTypePlayer,
-		AnimationOnly:                 false,
-		StartTime:                     1200,
-		StartAmmo:                     6,
-	})
+	for _, w := range enemyCatalog.weapons {
+		w := w
+		weaponTypes = append(weaponTypes, &w)
+	}
 	return weaponTypes
 }
 
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"image"
+	"slices"
+)
+
+const DefaultCellSize = 64
+
+type cell struct {
+	X, Y int
+}
+
+// SpatialHash buckets rectangles into a uniform grid, so that collision
+// checks only have to look at rectangles sharing a cell.
+type SpatialHash struct {
+	cellSize int
+	cells    map[cell][]int
+	marks    []int
+	query    int
+}
+
+func NewSpatialHash(cellSize int) *SpatialHash {
+	return &SpatialHash{
+		cellSize: cellSize,
+		cells:    make(map[cell][]int),
+	}
+}
+
+// Clear empties the grid but keeps its memory for the next tick.
+func (h *SpatialHash) Clear() {
+	for k, v := range h.cells {
+		h.cells[k] = v[:0]
+	}
+	h.marks = h.marks[:0]
+}
+
+// Insert adds a rectangle under the given index. Indexes are expected to be
+// 0, 1, 2, ... in the order of insertion.
+func (h *SpatialHash) Insert(i int, r image.Rectangle) {
+	for len(h.marks) <= i {
+		h.marks = append(h.marks, 0)
+	}
+	if r.Empty() {
+		return
+	}
+	minX, minY, maxX, maxY := h.cellRange(r)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			c := cell{x, y}
+			h.cells[c] = append(h.cells[c], i)
+		}
+	}
+}
+
+// Query appends to dst the indexes of all rectangles sharing a cell with r.
+// They come in ascending order, so callers see them in insertion order just
+// like with a plain loop.
+func (h *SpatialHash) Query(r image.Rectangle, dst []int) []int {
+	dst = dst[:0]
+	if r.Empty() {
+		return dst
+	}
+	h.query++
+	minX, minY, maxX, maxY := h.cellRange(r)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			for _, i := range h.cells[cell{x, y}] {
+				if h.marks[i] != h.query {
+					h.marks[i] = h.query
+					dst = append(dst, i)
+				}
+			}
+		}
+	}
+	slices.Sort(dst)
+	return dst
+}
+
+func (h *SpatialHash) cellRange(r image.Rectangle) (minX, minY, maxX, maxY int) {
+	return floorDiv(r.Min.X, h.cellSize), floorDiv(r.Min.Y, h.cellSize), floorDiv(r.Max.X-1, h.cellSize), floorDiv(r.Max.Y-1, h.cellSize)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
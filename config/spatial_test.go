@@ -0,0 +1,71 @@
+package config
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+// A late level: seven batches of 80 enemies against a few hundred
+// projectiles on a 1920x1080 screen.
+const (
+	benchEnemies     = 7 * 80
+	benchProjectiles = 400
+)
+
+// benchHits keeps the compiler from dropping the checks.
+var benchHits int
+
+func benchRects(rng *rand.Rand, n, w, h int) []image.Rectangle {
+	rects := make([]image.Rectangle, n)
+	for i := range rects {
+		x := rng.Intn(ScreenWidth1920x1080 - w)
+		y := rng.Intn(ScreenHeight1920x1080 - h)
+		rects[i] = image.Rect(x, y, x+w, y+h)
+	}
+	return rects
+}
+
+func benchInput() (enemies, projectiles []image.Rectangle) {
+	rng := rand.New(rand.NewSource(1))
+	return benchRects(rng, benchEnemies, 48, 48), benchRects(rng, benchProjectiles, 8, 24)
+}
+
+// BenchmarkAllPairs is the plain loop the collision checks used before the
+// spatial hash.
+func BenchmarkAllPairs(b *testing.B) {
+	enemies, projectiles := benchInput()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, e := range enemies {
+			for _, p := range projectiles {
+				if IntersectRect(e, p) {
+					benchHits++
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkSpatialHash indexes the projectiles every iteration, the way the
+// game does every tick.
+func BenchmarkSpatialHash(b *testing.B) {
+	enemies, projectiles := benchInput()
+	h := NewSpatialHash(DefaultCellSize)
+	var candidates []int
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		h.Clear()
+		for i, p := range projectiles {
+			h.Insert(i, p)
+		}
+		for _, e := range enemies {
+			candidates = h.Query(e, candidates)
+			for _, i := range candidates {
+				if IntersectRect(e, projectiles[i]) {
+					benchHits++
+				}
+			}
+		}
+	}
+}
@@ -1,14 +1,18 @@
 package game
 
 import (
+	"astrogame/assets"
 	"astrogame/config"
 	"astrogame/objects"
+	"fmt"
 	"image"
+	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type Animation struct {
+	entity
 	position      config.Vector
 	rotationPoint config.Vector
 	rotation      float64
@@ -20,28 +24,38 @@ type Animation struct {
 	startAt       int // which frame
 	numberOfPlays int
 	currF         int
-	frameHeight   int
-	frameWidth    int
 	curTick       int
 	name          string
+	pivot         assets.Pivot
+	pooled        bool
 }
 
-func NewAnimation(position config.Vector, sprite *ebiten.Image, speed int, frameHeight int, frameWidth int, looping bool, name string, rotation float64) *Animation {
-	sprites, calcNumFrames := LoadSpritesheet(sprite, frameWidth, frameHeight)
-	return &Animation{
+// NewAnimation plays the clip of the spritesheet manifest with the name.
+func NewAnimation(position config.Vector, clip string, rotation float64) *Animation {
+	a := &Animation{}
+	a.init(position, clip, rotation)
+	return a
+}
+
+func (a *Animation) init(position config.Vector, clip string, rotation float64) {
+	c := assets.FindClip(clip)
+	if c == nil {
+		panic(fmt.Sprintf("animation: no clip %q in the spritesheet manifest", clip))
+	}
+	sprites, _ := LoadSpritesheet(c.Sheet, c.FrameWidth, c.FrameHeight)
+	*a = Animation{
 		position:      position,
-		sprites:       sprites,
-		speed:         speed,
-		looping:       looping,
+		sprites:       sprites[c.Start : c.Start+c.Frames],
+		speed:         max(int(math.Round(float64(ebiten.TPS())/c.FPS)), 1) - 1,
+		looping:       c.Loop,
 		run:           true,
-		numFrames:     calcNumFrames,
+		numFrames:     c.Frames,
 		startAt:       0,
 		numberOfPlays: 1,
 		currF:         0,
 		curTick:       0,
-		frameHeight:   frameHeight,
-		frameWidth:    frameWidth,
-		name:          name,
+		name:          clip,
+		pivot:         c.Pivot,
 		rotation:      rotation,
 	}
 }
@@ -66,18 +80,17 @@ func (a *Animation) Update() {
 
 func (a *Animation) Draw(screen *ebiten.Image) {
 	if a.currF < a.numFrames {
-		switch a.name {
-		case "engineFireburst":
-			objects.RotateAndTranslateAnimation(a.rotation, a.sprites[a.currF], screen, a.position.X, a.position.Y)
-		case "shield":
-			objects.RotateAndTranslateAnimation(a.rotation, a.sprites[a.currF], screen, a.position.X, a.position.Y)
-		default:
-			objects.RotateAndTranslateObject(a.rotation, a.sprites[a.currF], screen, a.position.X, a.position.Y)
-		}
+		objects.RotateAndTranslatePivot(a.rotation, a.sprites[a.currF], screen, a.position.X, a.position.Y, a.pivot.X, a.pivot.Y)
 	}
 }
 
+// LoadSpritesheet slices a spritesheet into frames. The frames are cached per
+// sheet and frame size and shared, so they must not be modified.
 func LoadSpritesheet(sourceImg *ebiten.Image, width int, height int) ([]*ebiten.Image, int) {
+	key := spritesheetKey{sourceImg, width, height}
+	if sprites, ok := spritesheetFrames[key]; ok {
+		return sprites, len(sprites)
+	}
 	sprites := []*ebiten.Image{}
 	numOfLines := sourceImg.Bounds().Dy() / height
 	numFramesInLine := sourceImg.Bounds().Dx() / width
@@ -93,5 +106,6 @@ func LoadSpritesheet(sourceImg *ebiten.Image, width int, height int) ([]*ebiten.
 		}
 	}
 
+	spritesheetFrames[key] = sprites
 	return sprites, numFramesInLine * numOfLines
 }
@@ -0,0 +1,350 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"astrogame/config"
+)
+
+const (
+	// bossIntroTicks is how long the entrance takes, the boss can't be hurt
+	// and doesn't fire until it is over
+	bossIntroTicks = 180
+	// bossSweepGap is the number of ticks between two shots of a sweep
+	bossSweepGap   = 4
+	bossFlashTicks = 6
+)
+
+type bossPart struct {
+	def   *config.BossPart
+	HP    int
+	MaxHP int
+	dead  bool
+	flash int
+}
+
+// Boss is a multi-part enemy ending a level. Its position is the center of
+// its core.
+type Boss struct {
+	entity
+	game      *Game
+	bossType  *config.BossType
+	parts     []bossPart
+	core      int
+	position  config.Vector
+	start     config.Vector
+	intro     int
+	phase     int
+	attack    int
+	cooldown  *config.Timer
+	direction float64
+	radial    float64
+	sweep     *config.BossAttack
+	sweepShot int
+	sweepTick int
+}
+
+func NewBoss(g *Game, bossType *config.BossType) *Boss {
+	b := &Boss{
+		game:      g,
+		bossType:  bossType,
+		core:      bossType.Core(),
+		intro:     bossIntroTicks,
+		cooldown:  config.NewTimer(0),
+		direction: 1,
+	}
+	for i := range bossType.Parts {
+		p := &bossType.Parts[i]
+		b.parts = append(b.parts, bossPart{def: p, HP: p.HP, MaxHP: p.HP})
+	}
+	_, extentY := b.extent()
+	b.start = config.Vector{X: g.Options.ScreenWidth / 2, Y: -extentY}
+	b.position = b.start
+	return b
+}
+
+// spawnBoss brings in the boss of the current level.
+func (g *Game) spawnBoss() {
+	g.boss = NewBoss(g, g.curLevel.Boss)
+	spawn(&g.world, &g.world.bosses, g.boss)
+	g.events.Publish(BossAppeared{Boss: g.boss})
+}
+
+func (g *Game) KillBoss(b *Boss) {
+	if !g.world.Despawn(b) {
+		return
+	}
+	for i := range b.parts {
+		if !b.parts[i].dead {
+			g.AddEffect(b.partPosition(i), "bigBlow", 0)
+		}
+	}
+	g.bossDefeated = true
+	g.events.Publish(BossDefeated{Boss: b})
+}
+
+func (b *Boss) Name() string {
+	return b.bossType.Name
+}
+
+// Entering reports whether the boss is still playing its entrance.
+func (b *Boss) Entering() bool {
+	return b.intro > 0
+}
+
+func (b *Boss) HP() int {
+	return b.parts[b.core].HP
+}
+
+func (b *Boss) MaxHP() int {
+	return b.parts[b.core].MaxHP
+}
+
+func (b *Boss) Update() {
+	for i := range b.parts {
+		if b.parts[i].flash > 0 {
+			b.parts[i].flash--
+		}
+	}
+	extentX, extentY := b.extent()
+	if b.intro > 0 {
+		b.intro--
+		t := 1 - float64(b.intro)/bossIntroTicks
+		t = 1 - (1-t)*(1-t)
+		targetY := extentY + b.game.Options.ScreenHeight*0.12
+		b.position.Y = b.start.Y + (targetY-b.start.Y)*t
+		return
+	}
+
+	b.position.X += b.direction * b.bossType.Phases[b.phase].Speed
+	if b.position.X < extentX {
+		b.position.X = extentX
+		b.direction = 1
+	} else if b.position.X > b.game.Options.ScreenWidth-extentX {
+		b.position.X = b.game.Options.ScreenWidth - extentX
+		b.direction = -1
+	}
+
+	if b.sweep != nil {
+		b.sweepTick--
+		if b.sweepTick <= 0 {
+			b.fireSweepShot()
+		}
+		return
+	}
+	b.cooldown.Update()
+	if !b.cooldown.IsReady() {
+		return
+	}
+	attacks := b.bossType.Phases[b.phase].Attacks
+	for range attacks {
+		a := &b.bossType.Attacks[attacks[b.attack%len(attacks)]]
+		b.attack++
+		if b.parts[a.Part].dead {
+			continue
+		}
+		b.fire(a)
+		b.cooldown.Restart(a.Cooldown)
+		return
+	}
+}
+
+// fire starts an attack. Rotations follow the enemy projectiles, zero fires
+// straight down.
+func (b *Boss) fire(a *config.BossAttack) {
+	from := b.partPosition(a.Part)
+	step := a.Angle * math.Pi / 180
+	spread := func(center float64) {
+		first := center - step*float64(a.Count-1)/2
+		for i := 0; i < a.Count; i++ {
+			b.shoot(a, from, first+step*float64(i))
+		}
+	}
+	switch a.Pattern {
+	case config.AttackAimed:
+		target := b.game.player.Collider()
+		dx := float64(target.Min.X+target.Dx()/2) - from.X
+		dy := float64(target.Min.Y+target.Dy()/2) - from.Y
+		spread(math.Atan2(-dx, dy))
+	case config.AttackSpread:
+		spread(0)
+	case config.AttackRadial:
+		for i := 0; i < a.Count; i++ {
+			b.shoot(a, from, b.radial+2*math.Pi*float64(i)/float64(a.Count))
+		}
+		b.radial += step
+	case config.AttackSweep:
+		b.sweep = a
+		b.sweepShot = 0
+		b.fireSweepShot()
+	}
+}
+
+// fireSweepShot fires the next shot of the running sweep, walking across its
+// arc the way the boss sways.
+func (b *Boss) fireSweepShot() {
+	a := b.sweep
+	if b.parts[a.Part].dead {
+		b.sweep = nil
+		return
+	}
+	width := a.Angle * math.Pi / 180
+	rotation := -width / 2
+	if a.Count > 1 {
+		rotation += width * float64(b.sweepShot) / float64(a.Count-1)
+	}
+	if b.direction < 0 {
+		rotation = -rotation
+	}
+	b.shoot(a, b.partPosition(a.Part), rotation)
+	b.sweepShot++
+	b.sweepTick = bossSweepGap
+	if b.sweepShot >= a.Count {
+		b.sweep = nil
+	}
+}
+
+func (b *Boss) shoot(a *config.BossAttack, from config.Vector, rotation float64) {
+	p := NewProjectile(b.game, from, rotation, a.Weapon, 0)
+	p.owner = config.OwnerEnemy
+	b.game.AddProjectile(p)
+}
+
+// hit deals damage to a part. Weak points pass it on to the core multiplied,
+// the boss dies with its core.
+func (b *Boss) hit(i int, damage int) {
+	if b.intro > 0 || !b.Alive() {
+		return
+	}
+	p := &b.parts[i]
+	if p.dead {
+		return
+	}
+	p.flash = bossFlashTicks
+	p.HP -= damage
+	if p.def.WeakPoint > 0 {
+		core := &b.parts[b.core]
+		core.flash = bossFlashTicks
+		core.HP -= int(math.Ceil(float64(damage) * p.def.WeakPoint))
+	}
+	if !p.def.Core && p.HP <= 0 {
+		p.dead = true
+		b.game.AddEffect(b.partPosition(i), "enemyBlow", 0)
+		b.game.events.Publish(BossPartDestroyed{Boss: b, Part: p.def.Name})
+	}
+
+	core := b.parts[b.core]
+	if core.HP <= 0 {
+		b.game.KillBoss(b)
+		return
+	}
+	phases := b.bossType.Phases
+	for b.phase < len(phases)-1 && float64(core.HP) <= phases[b.phase+1].Below*float64(core.MaxHP) {
+		b.phase++
+		b.attack = 0
+		b.game.events.Publish(BossPhaseChanged{Boss: b, Phase: b.phase})
+	}
+}
+
+// sprite is the part sprite scaled for the current resolution.
+func (b *Boss) sprite(i int) *ebiten.Image {
+	p := b.parts[i].def
+	return b.game.sprites.Scaled(p.Sprite, b.game.Options.EnemyScale*p.Scale)
+}
+
+// partPosition returns the center of a part.
+func (b *Boss) partPosition(i int) config.Vector {
+	core := b.sprite(b.core).Bounds()
+	offset := b.parts[i].def.Offset
+	return config.Vector{
+		X: b.position.X + offset.X*float64(core.Dx()),
+		Y: b.position.Y + offset.Y*float64(core.Dy()),
+	}
+}
+
+func (b *Boss) partCollider(i int) image.Rectangle {
+	bounds := b.sprite(i).Bounds()
+	center := b.partPosition(i)
+	topLeft := image.Point{
+		X: int(center.X) - bounds.Dx()/2,
+		Y: int(center.Y) - bounds.Dy()/2,
+	}
+	return image.Rectangle{Min: topLeft, Max: topLeft.Add(image.Point{X: bounds.Dx(), Y: bounds.Dy()})}
+}
+
+// extent is how far the parts reach from the center of the core.
+func (b *Boss) extent() (float64, float64) {
+	var x, y float64
+	for i := range b.parts {
+		r := b.partCollider(i)
+		x = max(x, math.Abs(float64(r.Min.X)-b.position.X), math.Abs(float64(r.Max.X)-b.position.X))
+		y = max(y, math.Abs(float64(r.Min.Y)-b.position.Y), math.Abs(float64(r.Max.Y)-b.position.Y))
+	}
+	return x, y
+}
+
+func (b *Boss) Draw(screen *ebiten.Image) {
+	// The core goes first so that the other parts sit on top of it
+	order := []int{b.core}
+	for i := range b.parts {
+		if i != b.core {
+			order = append(order, i)
+		}
+	}
+	for _, i := range order {
+		p := b.parts[i]
+		if p.dead {
+			continue
+		}
+		r := b.partCollider(i)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(r.Min.X), float64(r.Min.Y))
+		if p.flash > 0 {
+			op.ColorScale.Scale(1, 0.4, 0.4, 1)
+		}
+		screen.DrawImage(b.sprite(i), op)
+	}
+}
+
+// drawBossUI draws the HP bar of the boss under the score, with a tick where
+// each phase begins. The bar fills up during the entrance, which also shows a
+// warning.
+func (g *Game) drawBossUI(screen *ebiten.Image) {
+	b := g.boss
+	if b == nil || !b.Alive() {
+		return
+	}
+	width := float32(g.Options.ScreenWidth * 0.5)
+	height := 12 * float32(g.Options.ResolutionMultiplerY)
+	x := (float32(g.Options.ScreenWidth) - width) / 2
+	y := 90 * float32(g.Options.ResolutionMultiplerY)
+	fill := float32(b.HP()) / float32(b.MaxHP())
+	if b.Entering() {
+		fill = 1 - float32(b.intro)/bossIntroTicks
+	}
+
+	text.Draw(screen, b.Name(), g.Options.SmallFont, int(x), int(y)-6, color.White)
+	vector.DrawFilledRect(screen, x-2, y-2, width+4, height+4, color.RGBA{255, 255, 255, 255}, false)
+	vector.DrawFilledRect(screen, x, y, width, height, color.RGBA{100, 100, 100, 255}, false)
+	vector.DrawFilledRect(screen, x, y, width*fill, height, color.RGBA{179, 14, 14, 255}, false)
+	for _, p := range b.bossType.Phases[1:] {
+		tickX := x + width*float32(p.Below)
+		vector.StrokeLine(screen, tickX, y, tickX, y+height, 2, color.RGBA{255, 255, 255, 255}, false)
+	}
+
+	if b.Entering() && (b.intro/20)%2 == 0 {
+		warning := "WARNING"
+		bounds := text.BoundString(g.Options.ScoreFont, warning)
+		text.Draw(screen, warning, g.Options.ScoreFont, int(g.Options.ScreenWidth)/2-bounds.Dx()/2, int(g.Options.ScreenHeight)/2, color.RGBA{179, 14, 14, 255})
+		name := fmt.Sprintf("%s approaching", b.Name())
+		bounds = text.BoundString(g.Options.InfoFont, name)
+		text.Draw(screen, name, g.Options.InfoFont, int(g.Options.ScreenWidth)/2-bounds.Dx()/2, int(g.Options.ScreenHeight)/2+g.Options.ScreenFontHeight*2, color.White)
+	}
+}
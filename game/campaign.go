@@ -0,0 +1,151 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"astrogame/assets"
+	"astrogame/config"
+)
+
+const (
+	campaignPath    = "data/campaign.json"
+	campaignVersion = 1
+)
+
+// Campaign says which levels of a run are hand-authored. A slot without a
+// file keeps the generated level, and slots past the generated ones must name
+// a file.
+type Campaign struct {
+	Version int            `json:"version"`
+	Levels  []campaignSlot `json:"levels"`
+
+	files []*config.LevelFile
+}
+
+type campaignSlot struct {
+	File string `json:"file,omitempty"`
+}
+
+var campaign *Campaign
+
+func currentCampaign() *Campaign {
+	if campaign == nil {
+		err := assets.LoadData(campaignPath, func(data []byte) error {
+			var err error
+			campaign, err = decodeCampaign(assets.FS(), campaignPath, data)
+			return err
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return campaign
+}
+
+// SetCampaign makes the levels generated from now on follow c.
+func SetCampaign(c *Campaign) {
+	campaign = c
+}
+
+// ReadCampaign reads the campaign at name in fsys. Level files are looked up
+// next to it, and every one is checked against the enemy catalog and the
+// weapon registry.
+func ReadCampaign(fsys fs.FS, name string) (*Campaign, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCampaign(fsys, name, data)
+}
+
+func decodeCampaign(fsys fs.FS, name string, data []byte) (*Campaign, error) {
+	var c Campaign
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if c.Version != campaignVersion {
+		return nil, fmt.Errorf("%s: unsupported version %d", name, c.Version)
+	}
+
+	var errs []error
+	for i, slot := range c.Levels {
+		if slot.File == "" {
+			if i >= generatedLevelCount {
+				errs = append(errs, fmt.Errorf("levels[%d]: only the first %d levels can be generated", i, generatedLevelCount))
+			}
+			c.files = append(c.files, nil)
+			continue
+		}
+		file := path.Join(path.Dir(name), slot.File)
+		f, err := readLevelFile(fsys, file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("levels[%d] %s: %w", i, file, err))
+		}
+		c.files = append(c.files, f)
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &c, nil
+}
+
+func readLevelFile(fsys fs.FS, name string) (*config.LevelFile, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := config.ReadLevelFile(data)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for s, stage := range f.Stages {
+		for i, it := range stage.Items {
+			if it.Weapon == "" {
+				continue
+			}
+			d := weaponDef(it.Weapon)
+			switch {
+			case d == nil:
+				errs = append(errs, fmt.Errorf("stages[%d].items[%d]: no weapon %q", s, i, it.Weapon))
+			case it.Kind == config.ItemKindWeapon && d.Slot != slotPrimary,
+				it.Kind == config.ItemKindSecondary && d.Slot != slotSecondary,
+				it.Kind == config.ItemKindAmmo && d.Slot == slotUnique:
+				errs = append(errs, fmt.Errorf("stages[%d].items[%d]: %s weapon %q can't be a %s item", s, i, d.Slot, it.Weapon, it.Kind))
+			}
+		}
+	}
+	return f, errors.Join(errs...)
+}
+
+// apply swaps the authored levels into the generated ones.
+func (c *Campaign) apply(levels []*config.Level) []*config.Level {
+	for i, f := range c.files {
+		if f == nil {
+			continue
+		}
+		l, err := f.ToLevel()
+		if err != nil {
+			// checked when the campaign was read
+			panic(err)
+		}
+		if l.BgImg == nil {
+			l.BgImg = assets.Backgrounds[i%len(assets.Backgrounds)]
+		}
+		if i < len(levels) {
+			levels[i] = l
+		} else {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
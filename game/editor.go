@@ -0,0 +1,842 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"astrogame/assets"
+	"astrogame/config"
+)
+
+const (
+	editorDir         = "levels"
+	editorBuiltinGlob = "data/levels/*.json"
+	maxLevelName      = 24
+	healItemSprite    = "img/Items/heal_item.png"
+	shieldItemSprite  = "img/Ships/shield.png"
+)
+
+var itemKinds = []string{config.ItemKindWeapon, config.ItemKindSecondary, config.ItemKindAmmo, config.ItemKindHeal, config.ItemKindShield}
+
+type editorRowKind int
+
+const (
+	rowLevel editorRowKind = iota
+	rowStage
+	rowItem
+	rowWave
+	rowBatch
+)
+
+// editorRow is one line of the level tree. Index is the item or the batch.
+type editorRow struct {
+	kind  editorRowKind
+	stage int
+	wave  int
+	index int
+}
+
+// editorField is a value of the selected row, changed with left and right.
+type editorField struct {
+	label  string
+	value  string
+	change func(d int)
+}
+
+type editorFile struct {
+	label string
+	path  string
+	read  func() ([]byte, error)
+}
+
+type editorMode int
+
+const (
+	editorTree editorMode = iota
+	editorNaming
+	editorLoading
+)
+
+// levelEditor edits a level file as a tree of stages, items, waves and
+// batches, and play-tests it from any wave.
+type levelEditor struct {
+	sceneHooks
+	game    *Game
+	file    *config.LevelFile
+	path    string
+	dirty   bool
+	rows    []editorRow
+	sel     int
+	field   int
+	mode    editorMode
+	name    textField
+	files   []editorFile
+	fileSel int
+	ship    int
+	status  string
+	leaving bool
+
+	// what the game had before a play-test
+	events        *EventBus
+	persist       bool
+	recordReplays bool
+}
+
+func (s *levelEditor) Enter() {
+	if s.file == nil {
+		s.newLevel()
+	}
+	s.mode = editorTree
+	s.leaving = false
+	s.buildRows()
+}
+
+func (s *levelEditor) newLevel() {
+	s.file = config.NewLevelFile("NEW LEVEL")
+	s.file.Stages = []config.StageFile{newEditorStage()}
+	s.path = ""
+	s.dirty = false
+	s.sel = 0
+	s.field = 0
+}
+
+func newEditorStage() config.StageFile {
+	return config.StageFile{Waves: []config.WaveFile{newEditorWave()}}
+}
+
+func newEditorWave() config.WaveFile {
+	return config.WaveFile{Batches: []config.BatchFile{newEditorBatch()}}
+}
+
+func newEditorBatch() config.BatchFile {
+	return config.BatchFile{
+		Body:   config.EnemyBodyNames()[0],
+		Weapon: config.EnemyWeaponNames()[0],
+		Count:  6,
+	}
+}
+
+func newEditorItem() config.ItemFile {
+	it := config.ItemFile{Velocity: 1.4, SpawnTime: config.Duration(6 * time.Second)}
+	setItemKind(&it, config.ItemKindHeal)
+	return it
+}
+
+func copyStageFile(st config.StageFile) config.StageFile {
+	c := st
+	c.Items = slices.Clone(st.Items)
+	c.Waves = slices.Clone(st.Waves)
+	for i := range c.Waves {
+		c.Waves[i].Batches = slices.Clone(st.Waves[i].Batches)
+	}
+	return c
+}
+
+func (s *levelEditor) buildRows() {
+	s.rows = []editorRow{{kind: rowLevel}}
+	for si, st := range s.file.Stages {
+		s.rows = append(s.rows, editorRow{kind: rowStage, stage: si})
+		for i := range st.Items {
+			s.rows = append(s.rows, editorRow{kind: rowItem, stage: si, index: i})
+		}
+		for wi, w := range st.Waves {
+			s.rows = append(s.rows, editorRow{kind: rowWave, stage: si, wave: wi})
+			for bi := range w.Batches {
+				s.rows = append(s.rows, editorRow{kind: rowBatch, stage: si, wave: wi, index: bi})
+			}
+		}
+	}
+	s.sel = max(min(s.sel, len(s.rows)-1), 0)
+}
+
+// selectRow rebuilds the tree and moves the cursor onto r when it exists.
+func (s *levelEditor) selectRow(r editorRow) {
+	s.buildRows()
+	if i := slices.Index(s.rows, r); i >= 0 {
+		s.sel = i
+	}
+	s.field = 0
+}
+
+func (s *levelEditor) Update() error {
+	g := s.game
+	switch s.mode {
+	case editorNaming:
+		s.name.Update(&g.input)
+		if g.input.IsKeyJustPressed(ebiten.KeyEnter) && len(s.name.text) > 0 {
+			s.file.Name = s.name.String()
+			s.dirty = true
+			s.mode = editorTree
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyEscape) {
+			s.mode = editorTree
+		}
+		return nil
+	case editorLoading:
+		if len(s.files) > 0 {
+			if g.input.IsKeyJustPressed(ebiten.KeyArrowDown) {
+				s.fileSel = (s.fileSel + 1) % len(s.files)
+			}
+			if g.input.IsKeyJustPressed(ebiten.KeyArrowUp) {
+				s.fileSel = (s.fileSel + len(s.files) - 1) % len(s.files)
+			}
+			if g.input.IsKeyJustPressed(ebiten.KeyEnter) {
+				s.load(s.files[s.fileSel])
+				s.mode = editorTree
+			}
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyEscape) {
+			s.mode = editorTree
+		}
+		return nil
+	}
+
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) {
+		if s.dirty && !s.leaving {
+			s.leaving = true
+			s.status = "Unsaved changes, [esc] again to leave"
+			return nil
+		}
+		g.scenes.Switch(g.menu)
+		return nil
+	}
+	if len(g.input.State().Keys) > 0 {
+		s.leaving = false
+	}
+
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		s.sel = (s.sel + 1) % len(s.rows)
+		s.field = 0
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		s.sel = (s.sel + len(s.rows) - 1) % len(s.rows)
+		s.field = 0
+	}
+	row := s.rows[s.sel]
+	fields := s.fields(row)
+	if len(fields) > 0 {
+		s.field = min(s.field, len(fields)-1)
+		if g.input.IsKeyJustPressed(ebiten.KeyTab) {
+			s.field = (s.field + 1) % len(fields)
+		}
+		f := fields[s.field]
+		d := 0
+		if g.input.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+			d--
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyArrowRight) {
+			d++
+		}
+		if d != 0 && f.change != nil {
+			f.change(d)
+			s.dirty = true
+		}
+	}
+
+	switch {
+	case g.input.IsKeyJustPressed(ebiten.KeyEnter) && row.kind == rowLevel:
+		s.name = textField{max: maxLevelName}
+		s.name.Set(s.file.Name)
+		s.mode = editorNaming
+	case g.input.IsKeyJustPressed(ebiten.KeyA):
+		s.add(row)
+	case g.input.IsKeyJustPressed(ebiten.KeyI):
+		s.addItem(row)
+	case g.input.IsKeyJustPressed(ebiten.KeyC):
+		s.duplicate(row)
+	case g.input.IsKeyJustPressed(ebiten.KeyDelete):
+		s.remove(row)
+	case g.input.IsKeyJustPressed(ebiten.KeyH):
+		s.ship = (s.ship + 1) % len(Ships())
+	case g.input.IsKeyJustPressed(ebiten.KeyP):
+		s.playtest(row)
+	case g.input.IsKeyJustPressed(ebiten.KeyS):
+		s.save()
+	case g.input.IsKeyJustPressed(ebiten.KeyL):
+		s.files = s.listFiles()
+		s.fileSel = 0
+		s.mode = editorLoading
+	case g.input.IsKeyJustPressed(ebiten.KeyN):
+		s.newLevel()
+		s.buildRows()
+		s.status = "New level"
+	}
+	return nil
+}
+
+// add appends a child to the selected row, or a sibling after an item or a
+// batch.
+func (s *levelEditor) add(row editorRow) {
+	f := s.file
+	switch row.kind {
+	case rowLevel:
+		f.Stages = append(f.Stages, newEditorStage())
+		s.selectRow(editorRow{kind: rowStage, stage: len(f.Stages) - 1})
+	case rowStage:
+		st := &f.Stages[row.stage]
+		st.Waves = append(st.Waves, newEditorWave())
+		s.selectRow(editorRow{kind: rowWave, stage: row.stage, wave: len(st.Waves) - 1})
+	case rowWave:
+		w := &f.Stages[row.stage].Waves[row.wave]
+		w.Batches = append(w.Batches, newEditorBatch())
+		s.selectRow(editorRow{kind: rowBatch, stage: row.stage, wave: row.wave, index: len(w.Batches) - 1})
+	case rowBatch:
+		w := &f.Stages[row.stage].Waves[row.wave]
+		w.Batches = slices.Insert(w.Batches, row.index+1, newEditorBatch())
+		s.selectRow(editorRow{kind: rowBatch, stage: row.stage, wave: row.wave, index: row.index + 1})
+	case rowItem:
+		st := &f.Stages[row.stage]
+		st.Items = slices.Insert(st.Items, row.index+1, newEditorItem())
+		s.selectRow(editorRow{kind: rowItem, stage: row.stage, index: row.index + 1})
+	}
+	s.dirty = true
+}
+
+// addItem drops a new item at the end of the stage of the selected row.
+func (s *levelEditor) addItem(row editorRow) {
+	if len(s.file.Stages) == 0 {
+		return
+	}
+	st := &s.file.Stages[row.stage]
+	st.Items = append(st.Items, newEditorItem())
+	s.selectRow(editorRow{kind: rowItem, stage: row.stage, index: len(st.Items) - 1})
+	s.dirty = true
+}
+
+func (s *levelEditor) duplicate(row editorRow) {
+	f := s.file
+	switch row.kind {
+	case rowStage:
+		f.Stages = slices.Insert(f.Stages, row.stage+1, copyStageFile(f.Stages[row.stage]))
+		s.selectRow(editorRow{kind: rowStage, stage: row.stage + 1})
+	case rowWave:
+		st := &f.Stages[row.stage]
+		w := st.Waves[row.wave]
+		w.Batches = slices.Clone(w.Batches)
+		st.Waves = slices.Insert(st.Waves, row.wave+1, w)
+		s.selectRow(editorRow{kind: rowWave, stage: row.stage, wave: row.wave + 1})
+	case rowBatch:
+		w := &f.Stages[row.stage].Waves[row.wave]
+		w.Batches = slices.Insert(w.Batches, row.index+1, w.Batches[row.index])
+		s.selectRow(editorRow{kind: rowBatch, stage: row.stage, wave: row.wave, index: row.index + 1})
+	case rowItem:
+		st := &f.Stages[row.stage]
+		st.Items = slices.Insert(st.Items, row.index+1, st.Items[row.index])
+		s.selectRow(editorRow{kind: rowItem, stage: row.stage, index: row.index + 1})
+	default:
+		return
+	}
+	s.dirty = true
+}
+
+func (s *levelEditor) remove(row editorRow) {
+	f := s.file
+	switch row.kind {
+	case rowStage:
+		f.Stages = slices.Delete(f.Stages, row.stage, row.stage+1)
+	case rowWave:
+		st := &f.Stages[row.stage]
+		st.Waves = slices.Delete(st.Waves, row.wave, row.wave+1)
+	case rowBatch:
+		w := &f.Stages[row.stage].Waves[row.wave]
+		w.Batches = slices.Delete(w.Batches, row.index, row.index+1)
+	case rowItem:
+		st := &f.Stages[row.stage]
+		st.Items = slices.Delete(st.Items, row.index, row.index+1)
+	default:
+		return
+	}
+	s.buildRows()
+	s.field = 0
+	s.dirty = true
+}
+
+func (s *levelEditor) fields(row editorRow) []editorField {
+	f := s.file
+	switch row.kind {
+	case rowLevel:
+		backgrounds, _ := fs.Glob(assets.FS(), "img/Backgrounds/*.png")
+		backgrounds = append([]string{""}, backgrounds...)
+		fields := []editorField{
+			{label: "Name", value: f.Name + "  [enter] rename"},
+			{label: "Background", value: orDefault(path.Base(f.Background), f.Background == "", "campaign slot"), change: func(d int) {
+				f.Background = cycle(backgrounds, f.Background, d)
+			}},
+			{label: "Boss", value: orDefault(bossName(f.Boss), f.Boss == nil, "none"), change: func(d int) {
+				name := cycle(append([]string{""}, config.BossNames()...), bossName(f.Boss), d)
+				if name == "" {
+					f.Boss = nil
+				} else if f.Boss == nil {
+					f.Boss = &config.BossFile{Name: name}
+				} else {
+					f.Boss.Name = name
+				}
+			}},
+		}
+		if f.Boss != nil {
+			fields = append(fields, editorField{label: "Boss budget", value: fmt.Sprint(f.Boss.Budget), change: func(d int) {
+				f.Boss.Budget = max(f.Boss.Budget+100*d, 0)
+			}})
+		}
+		return fields
+	case rowStage:
+		st := &f.Stages[row.stage]
+		return []editorField{
+			{label: "Meteors", value: fmt.Sprint(st.Meteors), change: func(d int) {
+				st.Meteors = max(st.Meteors+d, 0)
+			}},
+		}
+	case rowWave:
+		return []editorField{
+			{label: "Batches", value: fmt.Sprint(len(f.Stages[row.stage].Waves[row.wave].Batches))},
+		}
+	case rowItem:
+		it := &f.Stages[row.stage].Items[row.index]
+		fields := []editorField{
+			{label: "Kind", value: it.Kind, change: func(d int) {
+				setItemKind(it, cycle(itemKinds, it.Kind, d))
+			}},
+		}
+		if names := itemWeapons(it.Kind); len(names) > 0 {
+			fields = append(fields, editorField{label: "Weapon", value: it.Weapon, change: func(d int) {
+				it.Weapon = cycle(names, it.Weapon, d)
+				it.Sprite = itemSprite(it)
+			}})
+		}
+		switch it.Kind {
+		case config.ItemKindAmmo:
+			fields = append(fields, editorField{label: "Amount", value: fmt.Sprint(it.Amount), change: func(d int) {
+				it.Amount = max(it.Amount+5*d, 5)
+			}})
+		case config.ItemKindHeal, config.ItemKindShield:
+			fields = append(fields, editorField{label: "HP", value: fmt.Sprint(it.HP), change: func(d int) {
+				it.HP = max(it.HP+d, 1)
+			}})
+		}
+		return append(fields,
+			editorField{label: "Velocity", value: fmt.Sprint(it.Velocity), change: func(d int) {
+				it.Velocity = max(stepVelocity(it.Velocity, d), 0.1)
+			}},
+			editorField{label: "Spawn time", value: time.Duration(it.SpawnTime).String(), change: func(d int) {
+				it.SpawnTime = max(it.SpawnTime+config.Duration(d)*config.Duration(250*time.Millisecond), config.Duration(250*time.Millisecond))
+			}},
+		)
+	case rowBatch:
+		b := &f.Stages[row.stage].Waves[row.wave].Batches[row.index]
+		return []editorField{
+			{label: "Body", value: b.Body, change: func(d int) {
+				b.Body = cycle(config.EnemyBodyNames(), b.Body, d)
+			}},
+			{label: "Weapon", value: b.Weapon, change: func(d int) {
+				b.Weapon = cycle(config.EnemyWeaponNames(), b.Weapon, d)
+			}},
+			{label: "Count", value: fmt.Sprint(b.Count), change: func(d int) {
+				b.Count = max(b.Count+d, 1)
+			}},
+			{label: "Formation", value: orDefault(b.Formation, b.Formation == "", "auto"), change: func(d int) {
+				b.Formation = cycle(append([]string{""}, config.Formations...), b.Formation, d)
+			}},
+			{label: "Spawn time", value: orDefault(time.Duration(b.SpawnTime).String(), b.SpawnTime == 0, "body default"), change: func(d int) {
+				b.SpawnTime = max(b.SpawnTime+config.Duration(d)*config.Duration(250*time.Millisecond), 0)
+			}},
+			{label: "HP", value: orDefault(fmt.Sprint(b.HP), b.HP == 0, "body default"), change: func(d int) {
+				b.HP = max(b.HP+d, 0)
+			}},
+			{label: "Velocity", value: orDefault(fmt.Sprint(b.Velocity), b.Velocity == 0, "body default"), change: func(d int) {
+				b.Velocity = max(stepVelocity(b.Velocity, d), 0)
+			}},
+			{label: "Target", value: orDefault(b.Target, b.Target == "", "body default"), change: func(d int) {
+				b.Target = cycle([]string{"", config.TargetTypeStraight, config.TargetTypePlayer}, b.Target, d)
+			}},
+			{label: "Movement", value: orDefault(b.Movement, b.Movement == "", config.MovementStraight), change: func(d int) {
+				b.Movement = cycle(append([]string{""}, config.Movements[1:]...), b.Movement, d)
+			}},
+		}
+	}
+	return nil
+}
+
+func bossName(b *config.BossFile) string {
+	if b == nil {
+		return ""
+	}
+	return b.Name
+}
+
+func orDefault(value string, isDefault bool, def string) string {
+	if isDefault {
+		return def
+	}
+	return value
+}
+
+// cycle returns the value d steps away from cur in list, wrapping around.
+func cycle(list []string, cur string, d int) string {
+	i := max(slices.Index(list, cur), 0)
+	return list[((i+d)%len(list)+len(list))%len(list)]
+}
+
+func stepVelocity(v float64, d int) float64 {
+	return math.Round((v+0.1*float64(d))*10) / 10
+}
+
+// itemWeapons lists the player weapons an item of the given kind can carry.
+func itemWeapons(kind string) []string {
+	var names []string
+	for _, d := range weapons().defs {
+		switch {
+		case kind == config.ItemKindWeapon && d.Slot == slotPrimary,
+			kind == config.ItemKindSecondary && d.Slot == slotSecondary,
+			kind == config.ItemKindAmmo && d.Slot != slotUnique:
+			names = append(names, d.Name)
+		}
+	}
+	return names
+}
+
+// setItemKind turns the item into another kind with sensible defaults.
+func setItemKind(it *config.ItemFile, kind string) {
+	it.Kind = kind
+	it.Weapon, it.Amount, it.HP = "", 0, 0
+	if names := itemWeapons(kind); len(names) > 0 {
+		it.Weapon = names[0]
+	}
+	switch kind {
+	case config.ItemKindAmmo:
+		it.Amount = 50
+	case config.ItemKindHeal, config.ItemKindShield:
+		it.HP = 10
+	}
+	it.Sprite = itemSprite(it)
+}
+
+func itemSprite(it *config.ItemFile) config.SpriteRef {
+	switch it.Kind {
+	case config.ItemKindHeal:
+		return config.SpriteRef{Path: healItemSprite, Scale: 0.5}
+	case config.ItemKindShield:
+		return config.SpriteRef{Path: shieldItemSprite, Scale: 0.8}
+	}
+	d := weaponDef(it.Weapon)
+	if d == nil {
+		return config.SpriteRef{}
+	}
+	ref := d.Sprite
+	if d.Item != nil {
+		ref = *d.Item
+	}
+	if it.Kind == config.ItemKindAmmo {
+		return config.SpriteRef{Path: ref.Path, Scale: 0.75}
+	}
+	return config.SpriteRef{Path: ref.Path, Scale: ref.Scale}
+}
+
+// problems sums up what keeps the level from being played.
+func problems(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return fmt.Sprintf("%s (and %d more)", lines[0], len(lines)-1)
+}
+
+func (s *levelEditor) save() {
+	p := s.path
+	if p == "" {
+		var err error
+		p, err = dataPath(editorDir, strings.ReplaceAll(strings.ToLower(s.file.Name), " ", "_")+".json")
+		if err != nil {
+			s.status = err.Error()
+			return
+		}
+	}
+	data, err := json.MarshalIndent(s.file, "", "  ")
+	if err == nil {
+		tmp := p + ".tmp"
+		err = os.WriteFile(tmp, data, 0o644)
+		if err == nil {
+			err = os.Rename(tmp, p)
+		}
+	}
+	if err != nil {
+		s.status = err.Error()
+		return
+	}
+	s.path = p
+	s.dirty = false
+	s.status = "Saved " + p
+	if _, err := s.file.ToLevel(); err != nil {
+		s.status += ", but: " + problems(err)
+	}
+}
+
+// listFiles finds the levels of the levels folder and the built-in ones.
+// Built-in levels are saved to the levels folder.
+func (s *levelEditor) listFiles() []editorFile {
+	var files []editorFile
+	dir, err := dataPath(editorDir)
+	if err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+				continue
+			}
+			p := filepath.Join(dir, e.Name())
+			files = append(files, editorFile{label: e.Name(), path: p, read: func() ([]byte, error) {
+				return os.ReadFile(p)
+			}})
+		}
+	}
+	builtin, _ := fs.Glob(assets.FS(), editorBuiltinGlob)
+	for _, name := range builtin {
+		name := name
+		files = append(files, editorFile{label: "built-in: " + path.Base(name), read: func() ([]byte, error) {
+			return assets.ReadFile(name)
+		}})
+	}
+	return files
+}
+
+func (s *levelEditor) load(f editorFile) {
+	data, err := f.read()
+	var file *config.LevelFile
+	if err == nil {
+		file, err = config.DecodeLevelFile(data)
+	}
+	if err != nil {
+		s.status = f.label + ": " + err.Error()
+		return
+	}
+	s.file = file
+	s.path = f.path
+	s.dirty = false
+	s.sel = 0
+	s.field = 0
+	s.buildRows()
+	s.status = "Loaded " + f.label
+}
+
+// playtest plays the level from the wave of the selected row. Nothing of the
+// play-test is saved, recorded or counted for the pilot, and it comes back to
+// the editor when it ends.
+func (s *levelEditor) playtest(row editorRow) {
+	g := s.game
+	level, err := s.file.ToLevel()
+	if err != nil {
+		s.status = problems(err)
+		return
+	}
+	if row.kind == rowLevel {
+		row = editorRow{kind: rowStage}
+	}
+	wave := row.wave
+	if row.kind == rowStage || row.kind == rowItem {
+		wave = 0
+	}
+	if level.BgImg == nil {
+		level.BgImg = assets.Backgrounds[0]
+	}
+	level.Number = 1
+
+	s.events = g.events
+	s.persist = g.persist
+	s.recordReplays = g.recordReplays
+	g.events = NewEventBus()
+	g.subscribeGameplay()
+	g.persist = false
+	g.recordReplays = false
+	g.playtest = s
+
+	g.world.Clear()
+	g.levels = []*config.Level{level}
+	g.curLevel = level
+	g.CurStage = &level.Stages[row.stage]
+	g.CurWave = &g.CurStage.Waves[wave]
+	g.bgImage = level.BgImg
+	g.score = 0
+	g.player = NewPlayer(g)
+	g.profile = NewPlayerProfile(g)
+	g.equipShip(Ships()[s.ship])
+	g.resetRunTimers()
+	g.runTicks = 0
+	g.beginStage()
+	g.scenes.Switch(g.play)
+	g.publishWaveStarted()
+}
+
+// stopPlaytest gives the game back what it had before the play-test.
+func (s *levelEditor) stopPlaytest() {
+	g := s.game
+	g.playtest = nil
+	g.events = s.events
+	g.persist = s.persist
+	g.recordReplays = s.recordReplays
+	g.clearRun()
+	g.scenes.Switch(s)
+}
+
+func (s *levelEditor) Draw(screen *ebiten.Image) {
+	g := s.game
+	g.DrawBg(screen)
+	grey := color.RGBA{100, 100, 100, 255}
+	red := color.RGBA{179, 14, 14, 255}
+	lineHeight := g.Options.ScreenFontHeight * 3 / 2
+	left := int(g.Options.ScreenWidth * 0.04)
+	right := int(g.Options.ScreenWidth * 0.55)
+	top := g.Options.ScreenYMenuHeight * 2
+	bottom := int(g.Options.ScreenHeight) - g.Options.ScreenYMenuHeight*2
+
+	title := "Level editor"
+	if s.path != "" {
+		title += ": " + filepath.Base(s.path)
+	}
+	if s.dirty {
+		title += " *"
+	}
+	text.Draw(screen, title, g.Options.ScoreFont, left, top, color.White)
+	y := top + g.Options.ScreenYMenuHeight
+
+	if s.mode == editorLoading {
+		if len(s.files) == 0 {
+			text.Draw(screen, "No level files", g.Options.SmallFont, left, y, color.White)
+		}
+		for i, f := range s.files {
+			c := color.RGBA{255, 255, 255, 255}
+			if i == s.fileSel {
+				c = red
+			}
+			text.Draw(screen, f.label, g.Options.SmallFont, left, y, c)
+			y += lineHeight
+		}
+		text.Draw(screen, "[enter] load  [esc] back", g.Options.SmallFont, left, int(g.Options.ScreenHeight)-g.Options.ScreenYMenuHeight, grey)
+		return
+	}
+
+	// Keep the selected row in view
+	visible := max((bottom-y)/lineHeight, 1)
+	first := max(min(s.sel-visible/2, len(s.rows)-visible), 0)
+	for i := first; i < len(s.rows) && i < first+visible; i++ {
+		c := color.RGBA{255, 255, 255, 255}
+		if i == s.sel {
+			c = red
+		}
+		text.Draw(screen, s.rowLabel(s.rows[i]), g.Options.SmallFont, left, y, c)
+		y += lineHeight
+	}
+
+	y = top + g.Options.ScreenYMenuHeight
+	row := s.rows[s.sel]
+	for i, f := range s.fields(row) {
+		c := color.RGBA{255, 255, 255, 255}
+		if i == s.field {
+			c = red
+		}
+		value := f.value
+		if f.change != nil {
+			value = "< " + value + " >"
+		}
+		if s.mode == editorNaming && row.kind == rowLevel && i == 0 {
+			value = s.name.String() + "_"
+		}
+		text.Draw(screen, f.label, g.Options.SmallFont, right, y, grey)
+		text.Draw(screen, value, g.Options.SmallFont, right+int(g.Options.ScreenWidth*0.14), y, c)
+		y += lineHeight
+	}
+	if row.kind == rowBatch {
+		s.drawFormation(screen, s.file.Stages[row.stage].Waves[row.wave].Batches[row.index], right, y+lineHeight, bottom)
+	}
+
+	text.Draw(screen, s.status, g.Options.SmallFont, left, bottom+lineHeight, color.White)
+	help := fmt.Sprintf("[tab/left/right] edit  [a] add  [i] item  [c] copy  [del] remove  [p] play-test as %s  [h] ship  [s] save  [l] load  [n] new  [esc] back", Ships()[s.ship].Name)
+	text.Draw(screen, help, g.Options.SmallFont, left, int(g.Options.ScreenHeight)-g.Options.ScreenYMenuHeight/2, grey)
+}
+
+func (s *levelEditor) rowLabel(r editorRow) string {
+	f := s.file
+	switch r.kind {
+	case rowStage:
+		label := fmt.Sprintf("  Stage %d", r.stage+1)
+		if m := f.Stages[r.stage].Meteors; m > 0 {
+			label += fmt.Sprintf("  %d meteors", m)
+		}
+		return label
+	case rowItem:
+		it := f.Stages[r.stage].Items[r.index]
+		what := it.Weapon
+		switch it.Kind {
+		case config.ItemKindHeal, config.ItemKindShield:
+			what = fmt.Sprintf("%d HP", it.HP)
+		case config.ItemKindAmmo:
+			what = fmt.Sprintf("%d %s", it.Amount, it.Weapon)
+		}
+		return fmt.Sprintf("    Item: %s %s at %v", it.Kind, what, time.Duration(it.SpawnTime))
+	case rowWave:
+		return fmt.Sprintf("    Wave %d", r.wave+1)
+	case rowBatch:
+		b := f.Stages[r.stage].Waves[r.wave].Batches[r.index]
+		return fmt.Sprintf("      %d x %s with %s, %s", b.Count, b.Body, b.Weapon, orDefault(b.Formation, b.Formation == "", "auto"))
+	}
+	return "Level: " + f.Name
+}
+
+// drawFormation shows where the enemies of a batch appear and the entry
+// paths they fly, shrunk into the right column. The grey box is the screen.
+func (s *levelEditor) drawFormation(screen *ebiten.Image, bf config.BatchFile, x, y, bottom int) {
+	g := s.game
+	batch, err := bf.ToBatch()
+	if err != nil {
+		text.Draw(screen, problems(err), g.Options.SmallFont, x, y, color.RGBA{179, 14, 14, 255})
+		return
+	}
+	slots := g.formationSlots(batch)
+	sprite := g.sprites.Scaled(batch.Type.Sprite, g.Options.EnemyScale)
+	w, h := float64(sprite.Bounds().Dx()), float64(sprite.Bounds().Dy())
+	minX, maxX, minY, maxY := 0.0, g.Options.ScreenWidth, 0.0, 0.0
+	extend := func(p config.Vector) {
+		minX, maxX = min(minX, p.X), max(maxX, p.X+w)
+		minY, maxY = min(minY, p.Y), max(maxY, p.Y+h)
+	}
+	for _, slot := range slots {
+		extend(slot.position)
+		for _, p := range slot.entry {
+			extend(p)
+		}
+	}
+	width := g.Options.ScreenWidth*0.96 - float64(x)
+	scale := min(width/(maxX-minX), float64(bottom-y)/(maxY-minY))
+	// toBox returns where the center of an enemy at p is drawn
+	toBox := func(p config.Vector) (float32, float32) {
+		return float32(float64(x) + (p.X+w/2-minX)*scale), float32(float64(y) + (p.Y+h/2-minY)*scale)
+	}
+	left, top := toBox(config.Vector{X: -w / 2, Y: -h / 2})
+	right, _ := toBox(config.Vector{X: g.Options.ScreenWidth - w/2})
+	_, screenBottom := toBox(config.Vector{Y: min(g.Options.ScreenHeight, maxY) - h/2})
+	vector.StrokeRect(screen, left, top, right-left, screenBottom-top, 1, color.RGBA{100, 100, 100, 255}, false)
+	for _, slot := range slots {
+		from := slot.position
+		for _, p := range slot.entry {
+			x0, y0 := toBox(from)
+			x1, y1 := toBox(p)
+			vector.StrokeLine(screen, x0, y0, x1, y1, 1, color.RGBA{179, 14, 14, 255}, false)
+			from = p
+		}
+		px, py := toBox(slot.position)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(float64(px)-w/2*scale, float64(py)-h/2*scale)
+		screen.DrawImage(sprite, op)
+	}
+}
@@ -2,7 +2,6 @@ package game
 
 import (
 	"image"
-	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -11,6 +10,7 @@ import (
 )
 
 type Enemy struct {
+	entity
 	game       *Game
 	position   config.Vector
 	target     config.Vector
@@ -20,6 +20,9 @@ type Enemy struct {
 	enemyType  *config.EnemyType
 	weapon     Weapon
 	HP         int
+	move       movement
+	// holdFire keeps the weapon quiet while the movement says so
+	holdFire bool
 }
 
 func NewEnemy(g *Game, target config.Vector, pos config.Vector, enType config.EnemyType) *Enemy {
@@ -33,14 +36,13 @@ func NewEnemy(g *Game, target config.Vector, pos config.Vector, enType config.En
 		X: normalizedDirection.X * enType.Velocity,
 		Y: normalizedDirection.Y * enType.Velocity,
 	}
-	enType.Sprite = objects.ScaleImg(enType.Sprite, 0.5)
-
 	e := &Enemy{
 		game:      g,
 		position:  pos,
 		movement:  movement,
 		enemyType: &enType,
 		HP:        enType.StartHP,
+		move:      straightMovement{},
 	}
 	if enType.WeaponType != nil {
 		e.weapon = NewEnemyWeapon(enType.WeaponType)
@@ -64,25 +66,9 @@ func (e *Enemy) SetDirection(target config.Vector, pos config.Vector, enType con
 }
 
 func (e *Enemy) Update() {
-	e.position.X += e.movement.X
-	e.position.Y += e.movement.Y
 	//e.rotation += e.enemyType.RotationSpeed
-	direction := config.Vector{
-		X: e.target.X - e.position.X,
-		Y: e.target.Y - e.position.Y,
-	}
-	normalizedDirection := direction.Normalize()
-
-	movement := config.Vector{
-		X: normalizedDirection.X * e.enemyType.Velocity,
-		Y: normalizedDirection.Y * e.enemyType.Velocity,
-	}
-	e.movement = movement
-	if e.TargetType == config.TargetTypePlayer {
-		e.rotation = math.Atan2(float64(e.target.Y-e.position.Y), float64(e.target.X-e.position.X))
-		e.rotation -= (90 * math.Pi) / 180
-	}
-	if e.weapon.projectile.wType != nil {
+	e.move.update(e)
+	if e.weapon.projectile.wType != nil && !e.holdFire {
 		e.weapon.shootCooldown.Update()
 		if e.weapon.shootCooldown.IsReady() {
 			if e.weapon.ammo <= 0 {
@@ -96,11 +82,11 @@ func (e *Enemy) Update() {
 }
 
 func (e *Enemy) Draw(screen *ebiten.Image) {
-	objects.RotateAndTranslateObject(e.rotation, e.enemyType.Sprite, screen, e.position.X, e.position.Y)
+	objects.RotateAndTranslateObject(e.rotation, e.sprite(), screen, e.position.X, e.position.Y)
 }
 
 func (e *Enemy) Collider() image.Rectangle {
-	bounds := e.enemyType.Sprite.Bounds()
+	bounds := e.sprite().Bounds()
 	return image.Rectangle{
 		Min: image.Point{
 			X: int(e.position.X),
@@ -112,3 +98,8 @@ func (e *Enemy) Collider() image.Rectangle {
 		},
 	}
 }
+
+// sprite is the enemy sprite scaled for the current resolution.
+func (e *Enemy) sprite() *ebiten.Image {
+	return e.game.sprites.Scaled(e.enemyType.Sprite, e.game.Options.EnemyScale)
+}
@@ -0,0 +1,142 @@
+package game
+
+import "reflect"
+
+// Event is anything that can be published on the event bus.
+type Event interface {
+	event()
+}
+
+type EnemyKilled struct {
+	Enemy *Enemy
+}
+
+type MeteorDestroyed struct {
+	Meteor *Meteor
+}
+
+type ProjectileFired struct {
+	Projectile *Projectile
+}
+
+// PlayerDamaged is published for every hit, Shield tells whether the shield
+// took it instead of the hull.
+type PlayerDamaged struct {
+	Damage int
+	Shield bool
+}
+
+type ShieldBroken struct{}
+
+type ItemPicked struct {
+	Item *Item
+}
+
+type WaveStarted struct {
+	Level int
+	Stage int
+	Wave  int
+}
+
+type StageCleared struct {
+	Level int
+	Stage int
+}
+
+type LevelCleared struct {
+	Level int
+}
+
+type PlayerDied struct{}
+
+// RunCompleted is published when the last level has been cleared.
+type RunCompleted struct{}
+
+// BossAppeared is published when the boss of a level starts its entrance.
+type BossAppeared struct {
+	Boss *Boss
+}
+
+type BossPhaseChanged struct {
+	Boss  *Boss
+	Phase int
+}
+
+type BossPartDestroyed struct {
+	Boss *Boss
+	Part string
+}
+
+type BossDefeated struct {
+	Boss *Boss
+}
+
+func (EnemyKilled) event()       {}
+func (MeteorDestroyed) event()   {}
+func (ProjectileFired) event()   {}
+func (PlayerDamaged) event()     {}
+func (ShieldBroken) event()      {}
+func (ItemPicked) event()        {}
+func (WaveStarted) event()       {}
+func (StageCleared) event()      {}
+func (LevelCleared) event()      {}
+func (PlayerDied) event()        {}
+func (RunCompleted) event()      {}
+func (BossAppeared) event()      {}
+func (BossPhaseChanged) event()  {}
+func (BossPartDestroyed) event() {}
+func (BossDefeated) event()      {}
+
+// EventBus delivers events synchronously to the handlers subscribed to their
+// type, in the order the handlers were added.
+type EventBus struct {
+	handlers map[reflect.Type][]func(Event)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[reflect.Type][]func(Event)),
+	}
+}
+
+// Subscribe registers fn for every published event of type E.
+func Subscribe[E Event](b *EventBus, fn func(E)) {
+	t := reflect.TypeOf((*E)(nil)).Elem()
+	b.handlers[t] = append(b.handlers[t], func(e Event) {
+		fn(e.(E))
+	})
+}
+
+func (b *EventBus) Publish(e Event) {
+	for _, h := range b.handlers[reflect.TypeOf(e)] {
+		h(e)
+	}
+}
+
+// subscribeGameplay wires the rules of the game itself to the bus.
+func (g *Game) subscribeGameplay() {
+	Subscribe(g.events, func(e EnemyKilled) {
+		g.score++
+		g.profile.credits += 10
+	})
+	Subscribe(g.events, func(e MeteorDestroyed) {
+		g.score++
+	})
+	Subscribe(g.events, func(e BossPartDestroyed) {
+		g.score++
+		g.profile.credits += 10
+	})
+	Subscribe(g.events, func(e BossDefeated) {
+		g.score += 10
+		g.profile.credits += 100
+	})
+	Subscribe(g.events, func(e ItemPicked) {
+		e.Item.ApplyTo(g.player)
+	})
+	Subscribe(g.events, func(e PlayerDied) {
+		g.endRun()
+	})
+	Subscribe(g.events, func(e RunCompleted) {
+		g.endRun()
+	})
+}
@@ -0,0 +1,283 @@
+package game
+
+import (
+	"math"
+
+	"astrogame/config"
+)
+
+// formationSlot is where an enemy of a batch appears and the path it flies
+// onto the screen before its movement takes over. Most formations appear
+// above the screen and need no entry.
+type formationSlot struct {
+	position config.Vector
+	entry    []config.Vector
+}
+
+// formation lays out the enemies of a batch, one slot for each.
+type formation func(g *Game, batch config.EnemyBatch) []formationSlot
+
+var formations = map[string]formation{
+	config.FormationCentered:  gridFormation,
+	config.FormationLines:     gridFormation,
+	config.FormationCheckmate: gridFormation,
+	config.FormationWedge:     wedgeFormation,
+	config.FormationArrowhead: arrowheadFormation,
+	config.FormationCircle:    circleFormation,
+	config.FormationSpiral:    spiralFormation,
+	config.FormationFlanks:    flanksFormation,
+	config.FormationColumns:   columnsFormation,
+	config.FormationSurround:  surroundFormation,
+}
+
+// formationSlots lays out a batch with its formation, unknown ones are
+// centered.
+func (g *Game) formationSlots(batch config.EnemyBatch) []formationSlot {
+	f, ok := formations[batch.StartPositionType]
+	if !ok {
+		batch.StartPositionType = config.FormationCentered
+		f = gridFormation
+	}
+	return f(g, batch)
+}
+
+// enemySize is the size of an enemy of the batch on the screen.
+func (g *Game) enemySize(batch config.EnemyBatch) (float64, float64) {
+	bounds := g.sprites.Scaled(batch.Type.Sprite, g.Options.EnemyScale).Bounds()
+	return float64(bounds.Dx()), float64(bounds.Dy())
+}
+
+// gridFormation fills lines above the screen, all of them centered, spread
+// further apart the higher they are, or in a checkerboard.
+func gridFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	var slots []formationSlot
+	elemInLineCount := 0
+	linesCount := 0.0
+	var xOffsetMod float64
+	enemyWidth := batch.Type.Sprite.Bounds().Dx() / 2
+	enemyHight := batch.Type.Sprite.Bounds().Dy() / 2
+	if batch.StartPositionType == config.FormationCentered {
+		xOffsetMod = (g.Options.ScreenWidth - float64(batch.Count*(enemyWidth+int(batch.StartPosOffset))-int(batch.StartPosOffset))) / 2
+	}
+	for i := 0; i < batch.Count; i++ {
+		var startPos config.Vector
+		switch batch.StartPositionType {
+		case config.FormationCentered:
+			xOffset := batch.StartPosOffset
+			elemInLine := int(g.Options.ScreenWidth) / (enemyWidth + int(xOffset))
+			if elemInLineCount == 0 {
+				xOffset = 0.0
+			}
+			if elemInLineCount >= elemInLine {
+				elemInLineCount = 0
+				linesCount++
+			}
+			startPos = config.Vector{
+				X: (float64(enemyWidth)+xOffset)*float64(elemInLineCount) + xOffsetMod,
+				Y: -(float64(enemyHight)*linesCount + float64(enemyHight*2)),
+			}
+		case config.FormationLines:
+			xOffset := batch.StartPosOffset
+			elemInLine := int(g.Options.ScreenWidth) / (enemyWidth + int(xOffset))
+			if elemInLineCount == 0 {
+				xOffset = 0.0
+			}
+			if elemInLineCount >= elemInLine {
+				elemInLineCount = 0
+				linesCount++
+			}
+			startPos = config.Vector{
+				X: (float64(enemyWidth) + xOffset) * float64(elemInLineCount),
+				Y: -(float64(enemyHight*2)*linesCount*linesCount + float64(enemyHight) + float64(enemyHight)),
+			}
+		case config.FormationCheckmate:
+			cellWidth := enemyWidth * 2
+			elemInLine := int(g.Options.ScreenWidth) / (cellWidth * 2)
+			if elemInLineCount >= elemInLine {
+				elemInLineCount = 0
+				linesCount++
+			}
+			startPos = config.Vector{
+				X: float64(cellWidth)*float64(elemInLineCount)*2 + float64(cellWidth),
+				Y: -(float64(enemyHight*4)*linesCount + 10),
+			}
+			if int(linesCount)%2 == 0 {
+				startPos = config.Vector{
+					X: float64(cellWidth) * float64(elemInLineCount) * 2,
+					Y: -(float64(enemyHight*4)*linesCount + 10),
+				}
+			}
+		}
+		slots = append(slots, formationSlot{position: startPos})
+		elemInLineCount++
+	}
+	return slots
+}
+
+// wedgeFormation is a V flying point first, the wings trail back and up.
+func wedgeFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	w, h := g.enemySize(batch)
+	ranks := float64(batch.Count / 2)
+	dx := math.Min(w*1.2, (g.Options.ScreenWidth/2-w)/math.Max(ranks, 1))
+	center := g.Options.ScreenWidth/2 - w/2
+	var slots []formationSlot
+	for i := 0; i < batch.Count; i++ {
+		rank := float64((i + 1) / 2)
+		side := 1.0
+		if i%2 == 0 {
+			side = -1
+		}
+		slots = append(slots, formationSlot{position: config.Vector{
+			X: center + side*rank*dx,
+			Y: -2*h - rank*h*0.8,
+		}})
+	}
+	return slots
+}
+
+// arrowheadFormation is a filled triangle, one enemy at the tip and one more
+// in every row behind it.
+func arrowheadFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	w, h := g.enemySize(batch)
+	rows := 0
+	for rows*(rows+1)/2 < batch.Count {
+		rows++
+	}
+	dx := math.Min(w*1.3, (g.Options.ScreenWidth-w)/float64(rows))
+	center := g.Options.ScreenWidth/2 - w/2
+	var slots []formationSlot
+	for row := 0; len(slots) < batch.Count; row++ {
+		for k := 0; k <= row && len(slots) < batch.Count; k++ {
+			slots = append(slots, formationSlot{position: config.Vector{
+				X: center + (float64(k)-float64(row)/2)*dx,
+				Y: -2*h - float64(row)*h*1.1,
+			}})
+		}
+	}
+	return slots
+}
+
+// circleFormation is a ring above the screen that comes down in one piece to
+// the upper part of the screen.
+func circleFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	w, h := g.enemySize(batch)
+	radius := float64(batch.Count) * w * 1.3 / (2 * math.Pi)
+	radius = math.Min(math.Max(radius, w*1.5), math.Min(g.Options.ScreenWidth, g.Options.ScreenHeight)*0.4)
+	center := config.Vector{X: g.Options.ScreenWidth/2 - w/2, Y: -radius - h}
+	drop := radius + h + g.Options.ScreenHeight*0.25
+	var slots []formationSlot
+	for i := 0; i < batch.Count; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(batch.Count)
+		p := config.Vector{X: center.X + radius*math.Cos(angle), Y: center.Y + radius*math.Sin(angle)}
+		slots = append(slots, formationSlot{
+			position: p,
+			entry:    []config.Vector{{X: p.X, Y: p.Y + drop}},
+		})
+	}
+	return slots
+}
+
+// spiralFormation comes down from above the screen and winds out around a
+// point in the upper part of the screen, the first enemy closest to it.
+func spiralFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	w, h := g.enemySize(batch)
+	maxRadius := math.Min(g.Options.ScreenWidth, g.Options.ScreenHeight) * 0.3
+	center := config.Vector{X: g.Options.ScreenWidth/2 - w/2, Y: g.Options.ScreenHeight * 0.3}
+	var slots []formationSlot
+	for i := 0; i < batch.Count; i++ {
+		share := 1.0
+		if batch.Count > 1 {
+			share = float64(i) / float64(batch.Count-1)
+		}
+		radius := w + (maxRadius-w)*share
+		angle := float64(i) * 0.7
+		slot := formationSlot{position: config.Vector{
+			X: center.X + radius*math.Cos(angle),
+			Y: -h - maxRadius + radius*math.Sin(angle),
+		}}
+		for k := 0; k < 4; k++ {
+			r := radius * (0.4 + 0.2*float64(k))
+			a := angle + float64(k)*math.Pi/2
+			slot.entry = append(slot.entry, config.Vector{X: center.X + r*math.Cos(a), Y: center.Y + r*math.Sin(a)})
+		}
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// flanksFormation splits the batch in two groups that enter from the left
+// and the right side of the screen.
+func flanksFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	w, h := g.enemySize(batch)
+	rows := max(int(g.Options.ScreenHeight*0.35/(h*1.3)), 1)
+	var slots []formationSlot
+	for i := 0; i < batch.Count; i++ {
+		k := i / 2
+		row, col := float64(k%rows), float64(k/rows)
+		y := g.Options.ScreenHeight*0.1 + row*h*1.3
+		start := config.Vector{X: -w - col*w*1.3, Y: y}
+		end := config.Vector{X: g.Options.ScreenWidth*0.05 + col*w*1.3, Y: y}
+		if i%2 == 1 {
+			start.X = g.Options.ScreenWidth + col*w*1.3
+			end.X = g.Options.ScreenWidth*0.95 - w - col*w*1.3
+		}
+		slots = append(slots, formationSlot{position: start, entry: []config.Vector{end}})
+	}
+	return slots
+}
+
+// columnsFormation lines the batch up in columns across the screen, every
+// other column half a step higher.
+func columnsFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	w, h := g.enemySize(batch)
+	cols := min(max((batch.Count+3)/4, 2), 8)
+	spacing := g.Options.ScreenWidth / float64(cols+1)
+	var slots []formationSlot
+	for i := 0; i < batch.Count; i++ {
+		col, row := i%cols, i/cols
+		slots = append(slots, formationSlot{position: config.Vector{
+			X: float64(col+1)*spacing - w/2,
+			Y: -2*h - float64(row)*h*1.6 - float64(col%2)*h*0.8,
+		}})
+	}
+	return slots
+}
+
+// surroundFormation closes a ring around the player. The enemies appear just
+// off the screen edge behind their place in the ring and fly in to it.
+func surroundFormation(g *Game, batch config.EnemyBatch) []formationSlot {
+	w, h := g.enemySize(batch)
+	width, height := g.Options.ScreenWidth, g.Options.ScreenHeight
+	player := g.player.Collider()
+	center := config.Vector{
+		X: float64(player.Min.X+player.Dx()/2) - w/2,
+		Y: float64(player.Min.Y+player.Dy()/2) - h/2,
+	}
+	radius := math.Min(width, height) * 0.35
+	var slots []formationSlot
+	for i := 0; i < batch.Count; i++ {
+		angle := 2*math.Pi*float64(i)/float64(batch.Count) - math.Pi/2
+		dir := config.Vector{X: math.Cos(angle), Y: math.Sin(angle)}
+		p := config.Vector{
+			X: math.Min(math.Max(center.X+radius*dir.X, 0), width-w),
+			Y: math.Min(math.Max(center.Y+radius*dir.Y, 0), height-h),
+		}
+		// Walk out from the ring until the enemy is off the screen
+		t := math.Inf(1)
+		if dir.X > 0 {
+			t = math.Min(t, (width-p.X)/dir.X)
+		} else if dir.X < 0 {
+			t = math.Min(t, (-w-p.X)/dir.X)
+		}
+		if dir.Y > 0 {
+			t = math.Min(t, (height-p.Y)/dir.Y)
+		} else if dir.Y < 0 {
+			t = math.Min(t, (-h-p.Y)/dir.Y)
+		}
+		slots = append(slots, formationSlot{
+			position: config.Vector{X: p.X + dir.X*t, Y: p.Y + dir.Y*t},
+			entry:    []config.Vector{p},
+		})
+	}
+	return slots
+}
@@ -7,11 +7,9 @@ import (
 	"fmt"
 	"image/color"
 	"math/rand"
-	"slices"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 	"golang.org/x/image/font"
@@ -19,6 +17,8 @@ import (
 
 type options struct {
 	Fullscreen              bool
+	DeviceScale             float64
+	ResolutionWidth         int
 	ResolutionMultipler     float64
 	ProjectileResMulti      float64
 	ResolutionMultiplerX    float64
@@ -38,6 +38,11 @@ type options struct {
 	SmallFont               font.Face
 	ProfileFont             font.Face
 	ProfileBigFont          font.Face
+	// Scale factors applied to the source sprites at the current resolution
+	EnemyScale      float64
+	ItemScale       float64
+	IconScale       float64
+	ProjectileScale float64
 }
 type Game struct {
 	Options            *options
@@ -45,20 +50,32 @@ type Game struct {
 	optionsMenu        *OptionsMenu
 	shipChoosingScreen *shipChoosingScreen
 	profile            *ProfileScreen
-	state              config.GameState
+	scenes             SceneManager
+	play               *playScene
+	pauseMenu          *PauseMenu
+	nameEntry          *nameEntryScreen
+	highScoresScreen   *highScoresScreen
+	highScores         *HighScoreTable
+	pilotsScreen       *pilotsScreen
+	pilots             *PilotRoster
+	pilot              *Pilot
+	hangar             *hangarScreen
+	editor             *levelEditor
+	playtest           *levelEditor
+	mods               *modsScreen
+	replayUpgrades     map[string]int
+	finishedRun        *HighScore
+	runTicks           int
+	checkpoint         stageCheckpoint
 	player             *Player
 	choosenStartShip   *Ship
 	meteorSpawnTimer   *config.Timer
-	meteors            []*Meteor
-	projectiles        []*Projectile
-	blows              []*Blow
-	beams              []*Beam
-	beamAnimations     []*BeamAnimation
-	enemyBeams         []*Beam
-	enemyProjectiles   []*Projectile
-	enemies            []*Enemy
-	items              []*Item
-	animations         []*Animation
+	world              World
+	sprites            *objects.SpriteCache
+	events             *EventBus
+	projectileHash     *config.SpatialHash
+	enemyProjHash      *config.SpatialHash
+	candidates         []int
 	bgImage            *ebiten.Image
 	score              int
 	viewport           viewport
@@ -71,16 +88,52 @@ type Game struct {
 	batchesSpawnTimer  *config.Timer
 	itemSpawnTimer     *config.Timer
 	CurWave            *config.Wave
+	boss               *Boss
+	bossDefeated       bool
 	started            bool
 	ResolutionChange   bool
+	input              Input
+	inputSource        InputSource
+	tick               int
+	seed               int64
+	fixedSeed          bool
+	rng                *rand.Rand
+	recordReplays      bool
+	persist            bool
+	settings           Settings
+	recording          *Replay
+	playback           *replayPlayer
 }
 
-func NewGame() *Game {
-	l := GenerateLevels()
-	scale := ebiten.DeviceScaleFactor()
+// NewGame creates the game for the window. A zero seed picks a new random
+// seed for every run, any other value makes every run reproducible. The
+// pilot picker is shown first.
+func NewGame(seed int64, pilots *PilotRoster, settings Settings) *Game {
+	g := newGame(ebiten.DeviceScaleFactor(), &ebitenInput{}, seed, settings)
+	g.recordReplays = true
+	g.persist = true
+	g.highScores = loadHighScores()
+	g.pilots = pilots
+	g.pilot = pilots.CurrentPilot()
+	g.subscribePilotStats()
+	g.subscribeMeta()
+	g.scenes.push(g.pilotsScreen)
+	g.menu.updateItems()
+	return g
+}
+
+func newGame(scale float64, src InputSource, seed int64, settings Settings) *Game {
+	fixedSeed := seed != 0
+	if !fixedSeed {
+		seed = newSeed()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	l := GenerateLevels(rng)
 	g := &Game{
 		Options: &options{
-			Fullscreen:              false,
+			Fullscreen:              settings.Fullscreen,
+			DeviceScale:             scale,
+			ResolutionWidth:         config.ScreenWidth1024X768,
 			ResolutionMultipler:     0.5,
 			ProjectileResMulti:      1,
 			ResolutionMultiplerX:    1,
@@ -101,7 +154,6 @@ func NewGame() *Game {
 			ProfileFont:             assets.ProfileFont1024x768,
 			ProfileBigFont:          assets.ProfileBigFont1024x768,
 		},
-		state:             config.MainMenu,
 		meteorSpawnTimer:  config.NewTimer(config.MeteorSpawnTime),
 		baseVelocity:      config.BaseMeteorVelocity,
 		velocityTimer:     config.NewTimer(config.MeteorSpeedUpTime),
@@ -115,12 +167,35 @@ func NewGame() *Game {
 		CurWave:           &l[0].Stages[0].Waves[0],
 		started:           false,
 		ResolutionChange:  false,
+		inputSource:       src,
+		seed:              seed,
+		fixedSeed:         fixedSeed,
+		rng:               rng,
+		sprites:           objects.NewSpriteCache(),
+		events:            NewEventBus(),
+		projectileHash:    config.NewSpatialHash(config.DefaultCellSize),
+		enemyProjHash:     config.NewSpatialHash(config.DefaultCellSize),
+		settings:          settings,
 	}
+	g.setResolution(settings.Resolution)
+	g.ResolutionChange = false
 	g.player = NewPlayer(g)
 	g.menu = NewMainMenu(g)
 	g.shipChoosingScreen = NewShipChoosingScreen(g)
 	g.optionsMenu = NewOptionsMenu(g)
 	g.profile = NewPlayerProfile(g)
+	g.pauseMenu = NewPauseMenu(g)
+	g.nameEntry = &nameEntryScreen{game: g}
+	g.highScoresScreen = &highScoresScreen{game: g, highlight: -1}
+	g.highScores = &HighScoreTable{Version: highScoresVersion}
+	g.pilotsScreen = &pilotsScreen{game: g}
+	g.hangar = &hangarScreen{game: g}
+	g.editor = &levelEditor{game: g}
+	g.mods = &modsScreen{game: g}
+	g.pilots = &PilotRoster{Version: pilotsVersion}
+	g.play = &playScene{game: g}
+	g.scenes.push(g.menu)
+	g.subscribeGameplay()
 
 	return g
 }
@@ -154,483 +229,30 @@ func (g *Game) MoveBgPosition() {
 }
 
 func (g *Game) Update() error {
-	g.MoveBgPosition()
-	switch g.state {
-	case config.ShipChoosingWindow:
-		g.shipChoosingScreen.Update()
-	case config.Options:
-		err := g.optionsMenu.Update()
-		if err != nil {
-			return err
-		}
-	case config.Profile:
-		g.profile.Update()
-	case config.MainMenu:
-		err := g.menu.Update()
-		if err != nil {
-			return err
-		}
-	case config.InGame:
-		// Main menu logic
-		if !g.started {
-			g.started = true
-			// Unlock the continue button
-			for i := range g.menu.Items {
-				if g.menu.Items[i].Label == "Continue game" {
-					g.menu.Items[i].Active = true
-				}
-			}
-		}
-
-		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
-			g.state = config.MainMenu
-		}
-
-		if inpututil.IsKeyJustPressed(ebiten.KeyP) {
-			for _, i := range g.profile.LeftBar.Items {
-				i.UpdatePrevValue(g)
-			}
-			for _, i := range g.profile.RightBar.Items {
-				i.UpdatePrevValue(g)
-			}
-			g.state = config.Profile
-		}
-
-		// Game logic
-		g.player.Update()
-
-		// Meteor spawning
-		g.meteorSpawnTimer.Update()
-		if g.meteorSpawnTimer.IsReady() {
-			g.meteorSpawnTimer.Reset()
-			if g.CurStage.MeteorsCount > 0 {
-				m := NewMeteor(g.baseVelocity, g)
-				g.meteors = append(g.meteors, m)
-				g.CurStage.MeteorsCount--
-			}
-		}
-
-		// Item spawning
-		g.itemSpawnTimer.Update()
-		if g.itemSpawnTimer.IsReady() {
-			if len(g.CurStage.Items) > 0 {
-				var target config.Vector
-				var startPos config.Vector
-				itemParam := g.CurStage.Items[0]
-				item := NewItem(g, target, startPos, &itemParam)
-				if len(g.CurStage.Items) > 1 {
-					g.itemSpawnTimer.Restart(g.CurStage.Items[1].ItemSpawnTime)
-				}
-				//g.itemSpawnTimer.Restart(g.CurStage.Items[1].ItemSpawnTime)
-				itemWidth := item.itemType.Sprite.Bounds().Dx()
-				itemHight := item.itemType.Sprite.Bounds().Dy()
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				posX := r.Intn(int(g.Options.ScreenWidth) - (itemWidth + itemWidth/2))
-				startPos = config.Vector{
-					X: float64(posX + itemWidth),
-					Y: -(float64(itemHight)),
-				}
-				target = config.Vector{
-					X: startPos.X,
-					Y: g.Options.ScreenHeight + 10,
-				}
-				item.SetDirection(target, startPos, &itemParam)
-				item.target = target
-				g.items = append(g.items, item)
-				g.CurStage.Items = slices.Delete(g.CurStage.Items, 0, 1)
-			}
-		}
-
-		// Enemy spawning
-		g.batchesSpawnTimer.Update()
-		if g.batchesSpawnTimer.IsReady() {
-			if len(g.CurWave.Batches) > 0 {
-				batch := g.CurWave.Batches[0]
-				if len(g.CurWave.Batches) > 1 {
-					g.batchesSpawnTimer = config.NewTimer(g.CurWave.Batches[1].BatchSpawnTime)
-				}
-				g.batchesSpawnTimer.Reset()
-				elemInLineCount := 0
-				linesCount := 0.0
-				var xOffsetMod float64
-				if batch.StartPositionType == "centered" {
-					eTst := NewEnemy(g, config.Vector{}, config.Vector{}, *batch.Type)
-					enemyWidthTst := eTst.enemyType.Sprite.Bounds().Dx() / 2
-					xOffsetMod = (g.Options.ScreenWidth - float64(batch.Count*(enemyWidthTst+int(batch.StartPosOffset))-int(batch.StartPosOffset))) / 2
-				}
-				for i := 0; i < batch.Count; i++ {
-					var target config.Vector
-					var startPos config.Vector
-					e := NewEnemy(g, target, startPos, *batch.Type)
-					e.TargetType = batch.TargetType
-					enemyWidth := e.enemyType.Sprite.Bounds().Dx() / 2
-					enemyHight := e.enemyType.Sprite.Bounds().Dy() / 2
-					switch batch.StartPositionType {
-					case "centered":
-						xOffset := batch.StartPosOffset
-						elemInLine := int(g.Options.ScreenWidth) / (enemyWidth + int(xOffset))
-						if elemInLineCount == 0 {
-							xOffset = 0.0
-						}
-						if elemInLineCount >= elemInLine {
-							elemInLineCount = 0
-							linesCount++
-						}
-						startPos = config.Vector{
-							X: (float64(enemyWidth)+xOffset)*float64(elemInLineCount) + xOffsetMod,
-							Y: -(float64(enemyHight)*linesCount + float64(enemyHight*2)),
-						}
-					case "lines":
-						xOffset := batch.StartPosOffset
-						elemInLine := int(g.Options.ScreenWidth) / (enemyWidth + int(xOffset))
-						if elemInLineCount == 0 {
-							xOffset = 0.0
-						}
-						if elemInLineCount >= elemInLine {
-							elemInLineCount = 0
-							linesCount++
-						}
-						startPos = config.Vector{
-							X: (float64(enemyWidth) + xOffset) * float64(elemInLineCount),
-							Y: -(float64(enemyHight*2)*linesCount*linesCount + float64(enemyHight) + float64(enemyHight)),
-						}
-					case "checkmate":
-						cellWidth := enemyWidth * 2
-						elemInLine := int(g.Options.ScreenWidth) / (cellWidth * 2)
-						if elemInLineCount >= elemInLine {
-							elemInLineCount = 0
-							linesCount++
-						}
-						startPos = config.Vector{
-							X: float64(cellWidth)*float64(elemInLineCount)*2 + float64(cellWidth),
-							Y: -(float64(enemyHight*4)*linesCount + 10),
-						}
-						if int(linesCount)%2 == 0 {
-							startPos = config.Vector{
-								X: float64(cellWidth) * float64(elemInLineCount) * 2,
-								Y: -(float64(enemyHight*4)*linesCount + 10),
-							}
-						}
-					}
-					switch batch.TargetType {
-					case config.OwnerPlayer:
-						target = config.Vector{
-							X: g.player.position.X,
-							Y: g.player.position.Y,
-						}
-					case "straight":
-						target = config.Vector{
-							X: startPos.X,
-							Y: g.Options.ScreenHeight + 10,
-						}
-					}
-					elemInLineCount++
-					e.SetDirection(target, startPos, *batch.Type)
-					e.target = target
-					g.enemies = append(g.enemies, e)
-				}
-				g.CurWave.Batches = slices.Delete(g.CurWave.Batches, 0, 1)
-			}
-		}
-
-		if len(g.CurWave.Batches) == 0 {
-			if g.CurWave.WaveId < len(g.CurStage.Waves)-1 {
-				g.CurWave = &g.CurStage.Waves[g.CurWave.WaveId+1]
-			} else {
-				if g.CurStage.MeteorsCount == 0 && g.CurStage.StageId < len(g.curLevel.Stages)-1 && len(g.CurStage.Items) == 0 {
-					g.CurStage = &g.curLevel.Stages[g.CurStage.StageId+1]
-					g.CurWave = &g.CurStage.Waves[0]
-				} else {
-					if g.curLevel.LevelId < len(g.levels)-1 {
-						g.curLevel = g.levels[g.curLevel.LevelId+1]
-						g.CurStage = &g.curLevel.Stages[0]
-						g.CurWave = &g.CurStage.Waves[0]
-					} else {
-						g.Reset()
-					}
-				}
-			}
-		}
-
-		for i, m := range g.meteors {
-			m.Update()
-			if m.Collider().Min.Y >= int(g.Options.ScreenHeight) && i < len(g.meteors) {
-				g.meteors = slices.Delete(g.meteors, i, i+1)
-			}
-		}
-
-		for i, p := range g.projectiles {
-			p.Update()
-			if i < len(g.projectiles) && (p.position.Y < 0 || p.position.Y >= g.Options.ScreenHeight+float64(p.wType.Sprite.Bounds().Dy())) {
-				g.projectiles[i].Destroy(g, i)
-			} else if i < len(g.projectiles) && (p.position.X < 0 || p.position.X > g.Options.ScreenWidth+float64(p.wType.Sprite.Bounds().Dx())) {
-				g.projectiles[i].Destroy(g, i)
-			}
-		}
-
-		for i, p := range g.enemyProjectiles {
-			if p.wType.TargetType == config.TargetTypePlayer && p.owner == config.OwnerEnemy {
-				p.target = config.Vector{
-					X: g.player.position.X,
-					Y: g.player.position.Y,
-				}
-			}
-			p.Update()
-			if p.position.Y >= g.Options.ScreenHeight && i < len(g.enemyProjectiles) {
-				g.enemyProjectiles = slices.Delete(g.enemyProjectiles, i, i+1)
-			}
-		}
-
-		for i, item := range g.items {
-			item.Update()
-			if item.position.Y >= g.Options.ScreenHeight && i < len(g.items) {
-				g.items = slices.Delete(g.items, i, i+1)
-			}
-		}
-
-		// Check for meteor/projectile collisions
-		// Check for meteor/enemy projectile collisions
-		for i, m := range g.meteors {
-			for j, b := range g.projectiles {
-				if config.IntersectRect(m.Collider(), b.Collider()) {
-					if (i < len(g.meteors)) && (j < len(g.projectiles)) {
-						g.meteors = append(g.meteors[:i], g.meteors[i+1:]...)
-						g.IntersectProjectile(b, j)
-						g.score++
-					}
-				}
-			}
-
-			for j, b := range g.enemyProjectiles {
-				if config.IntersectRect(m.Collider(), b.Collider()) {
-					if (i < len(g.meteors)-1) && (j < len(g.projectiles)-1) {
-						g.IntersectProjectile(b, j)
-					}
-				}
-			}
-		}
-
-		// Check for enemy/projectile collisions
-		// Check for enemy/player collisions
-		// Check for enemy/beam collisions
-		// Check for enemy/blow collisions
-		for i, m := range g.enemies {
-			if g.ResolutionChange {
-				m.enemyType.Sprite = objects.ScaleImg(m.enemyType.Sprite, g.Options.ResolutionMultipler)
-			}
-			if m.TargetType == config.TargetTypePlayer {
-				m.target = config.Vector{
-					X: g.player.position.X,
-					Y: g.player.position.Y,
-				}
-			}
-			m.Update()
-			if m.position.Y >= g.Options.ScreenHeight+float64(m.Collider().Dy()) && i < len(g.enemies) {
-				g.enemies = slices.Delete(g.enemies, i, i+1)
-			}
-
-			for j, b := range g.projectiles {
-				if config.IntersectRect(m.Collider(), b.Collider()) && b.owner == config.OwnerPlayer {
-					switch b.wType.WeaponName {
-					case config.BigBomb:
-						bounds := b.wType.Sprite.Bounds()
-						blow := NewBlow(b.position.X+float64(bounds.Dx()/2), b.position.Y+float64(bounds.Dy()/2), float64(bounds.Dx())*4, b.wType.Damage)
-						blow.Steps = 5
-						g.AddBlow(blow, m.position)
-					default:
-						m.HP -= b.wType.Damage
-						if m.HP <= 0 {
-							if (i < len(g.enemies)) && (j < len(g.projectiles)) {
-								g.KillEnemy(i)
-							}
-						}
-					}
-
-					if j < len(g.projectiles) {
-						g.projectiles[j].intercectAnimation.position = b.position
-						g.projectiles[j].AddAnimation(g)
-						g.projectiles[j].HP--
-						if g.projectiles[j].HP <= 0 {
-							g.projectiles[j].Destroy(g, j)
-						}
-					}
-				}
-			}
-
-			for _, blow := range g.blows {
-				if config.IntersectCircle(m.Collider(), blow.circle) {
-					m.HP -= blow.Damage
-					if m.HP <= 0 {
-						if i < len(g.enemies) {
-							g.KillEnemy(i)
-						}
-					}
-				}
-			}
-
-			if config.IntersectRect(m.Collider(), g.player.Collider()) {
-				if g.player.shield != nil {
-					g.player.shield = nil
-				} else {
-					g.Reset()
-					break
-				}
-			}
-
-			for _, beam := range g.beams {
-				if config.IntersectLine(beam.Line, m.Collider()) {
-					m.HP -= beam.Damage
-					if m.HP <= 0 {
-						if i < len(g.enemies) {
-							g.KillEnemy(i)
-						}
-					}
-				}
-			}
-		}
-		if g.ResolutionChange {
-			g.ResolutionChange = false
-		}
-		// Check for enemy projectile/player projectile collisions
-		for i, m := range g.enemyProjectiles {
-			for j, b := range g.projectiles {
-				if config.IntersectRect(m.Collider(), b.Collider()) {
-					if (i < len(g.enemyProjectiles)) && (j < len(g.projectiles)) {
-						g.IntersectProjectile(m, i)
-						g.IntersectProjectile(b, j)
-					}
-				}
-			}
-			for _, beam := range g.beams {
-				if config.IntersectLine(beam.Line, m.Collider()) {
-					if i < len(g.enemyProjectiles) {
-						g.IntersectProjectile(m, i)
-					}
-				}
-			}
-		}
-
-		// Check for projectiles/player collisions
-		for i, p := range g.enemyProjectiles {
-			if config.IntersectRect(p.Collider(), g.player.Collider()) {
-				if g.player.shield != nil {
-					g.player.shield.HP -= p.wType.Damage
-					if g.player.shield.HP <= 0 {
-						g.player.shield = nil
-					}
-				} else {
-					g.player.params.HP -= p.wType.Damage
-				}
-				if i < len(g.enemyProjectiles) {
-					g.IntersectProjectile(p, i)
-				}
-				if g.player.params.HP <= 0 {
-					g.Reset()
-					break
-				}
-			}
-		}
-
-		// Check for meteor/player collisions
-		for _, m := range g.meteors {
-			if config.IntersectRect(m.Collider(), g.player.Collider()) {
-				g.Reset()
-				break
-			}
-		}
-
-		// Check for item/player collisions
-		for i, item := range g.items {
-			if config.IntersectRect(item.Collider(), g.player.Collider()) {
-				item.CollideWithPlayer(g.player)
-				if i < len(g.items) {
-					g.items = append(g.items[:i], g.items[i+1:]...)
-				}
-			}
-		}
-
-		for i, ba := range g.beamAnimations {
-			ba.Update()
-			if ba.Step >= ba.Steps && i < len(g.beamAnimations) {
-				g.beamAnimations = slices.Delete(g.beamAnimations, i, i+1)
-			}
-		}
-
-		for i, a := range g.animations {
-			a.Update()
-			if a.currF >= a.numFrames && i < len(g.animations) && !a.looping {
-				g.animations = slices.Delete(g.animations, i, i+1)
-			}
-			if a.name == "shield" && g.player.shield == nil && i < len(g.animations) {
-				g.animations = slices.Delete(g.animations, i, i+1)
-			}
-		}
-
-		// Remove blows
-		for k, b := range g.blows {
-			b.Update()
-			if b.Step >= b.Steps && k < len(g.blows) {
-				g.blows = slices.Delete(g.blows, k, k+1)
-			}
-		}
-
-		// Remove beams
-		for k, b := range g.beams {
-			b.Update()
-			if b.Step >= b.Steps && k < len(g.beams) {
-				g.beams = slices.Delete(g.beams, k, k+1)
-			}
-		}
+	if g.playback != nil {
+		return g.playback.Update(g)
 	}
+	return g.update()
+}
 
-	return nil
+func (g *Game) update() error {
+	defer func() { g.tick++ }()
+	g.input.Update(g.inputSource.Next(g.tick))
+	if g.recording != nil {
+		g.recording.Frames = append(g.recording.Frames, g.input.State())
+	}
+	// The background only moves on its own in the menus before a run, during
+	// a run it belongs to the game and stands still whenever the game does
+	if !g.started {
+		g.BgMove()
+	}
+	return g.scenes.Update()
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	switch g.state {
-	case config.ShipChoosingWindow:
-		g.shipChoosingScreen.Draw(screen)
-	case config.Options:
-		g.optionsMenu.Draw(screen)
-	case config.Profile:
-		g.profile.Draw(screen)
-	case config.MainMenu:
-		g.menu.Draw(screen)
-	case config.InGame:
-		g.DrawBg(screen)
-
-		g.player.Draw(screen)
-
-		for _, ba := range g.beamAnimations {
-			ba.Draw(screen)
-		}
-
-		for _, e := range g.enemies {
-			e.Draw(screen)
-		}
-
-		for _, m := range g.meteors {
-			m.Draw(screen)
-		}
-
-		for _, p := range g.projectiles {
-			p.Draw(screen)
-		}
-
-		for _, p := range g.enemyProjectiles {
-			p.Draw(screen)
-		}
-
-		for _, i := range g.items {
-			i.Draw(screen)
-		}
-
-		for i, a := range g.animations {
-			if i < len(g.animations) {
-				a.Draw(screen)
-			}
-		}
-		g.drawUI(screen)
+	g.scenes.Draw(screen)
+	if g.playback != nil {
+		g.playback.Draw(g, screen)
 	}
 }
 
@@ -658,10 +280,7 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 
 	// Draw weapons
 	for i, w := range g.player.weapons {
-		object := objects.ScaleImg(w.projectile.wType.Sprite, 0.5)
-		if w.projectile.wType.ItemSprite != nil {
-			object = objects.ScaleImg(w.projectile.wType.ItemSprite, 0.5)
-		}
+		object := g.weaponIcon(w.projectile.wType, g.Options.IconScale)
 		offset := object.Bounds().Dx() * int(g.Options.ResolutionMultiplerX)
 		op := &ebiten.DrawImageOptions{}
 		op.GeoM.Translate(float64(i*offset+offset), g.Options.ScreenHeight-float64(offset*2*int(g.Options.ResolutionMultiplerY)))
@@ -676,7 +295,7 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 	for i, w := range g.player.secondaryWeapons {
 		startOffset := int(g.Options.ScreenWidth) - 40*int(g.Options.ResolutionMultiplerX)
 		offset := 20 * int(g.Options.ResolutionMultiplerX)
-		object := w.projectile.wType.Sprite
+		object := g.weaponSprite(w.projectile.wType, g.Options.ResolutionMultipler)
 		op := &ebiten.DrawImageOptions{}
 		op.GeoM.Translate(float64(startOffset-i*offset), g.Options.ScreenHeight-float64(offset*2*int(g.Options.ResolutionMultiplerY)))
 		if g.player.curSecondaryWeapon != nil && w.projectile.wType.WeaponName == g.player.curSecondaryWeapon.projectile.wType.WeaponName {
@@ -695,71 +314,103 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 
 	text.Draw(screen, fmt.Sprintf("Level: %v Stage: %v Wave: %v", g.curLevel.LevelId+1, g.CurStage.StageId+1, g.CurWave.WaveId), g.Options.InfoFont, 20, 50, color.White)
 	text.Draw(screen, fmt.Sprintf("%06d", g.score), g.Options.ScoreFont, int(g.Options.ScreenWidth)/2-100, 50, color.White)
+	text.Draw(screen, fmt.Sprintf("Seed: %v", g.seed), g.Options.SmallFont, 20, 50+g.Options.ScreenFontHeight, color.White)
+	g.drawBossUI(screen)
+}
+
+func (g *Game) Seed() int64 {
+	return g.seed
+}
+
+func newSeed() int64 {
+	return time.Now().UnixNano()
 }
 
 func (g *Game) AddProjectile(p *Projectile) {
 	if p.owner == config.OwnerPlayer {
-		g.projectiles = append(g.projectiles, p)
+		spawn(&g.world, &g.world.projectiles, p)
 	} else {
-		g.enemyProjectiles = append(g.enemyProjectiles, p)
+		spawn(&g.world, &g.world.enemyProjectiles, p)
 	}
+	g.events.Publish(ProjectileFired{Projectile: p})
 }
 
 func (g *Game) AddBeam(b *Beam) {
 	if b.owner == config.OwnerPlayer {
-		g.beams = append(g.beams, b)
+		spawn(&g.world, &g.world.beams, b)
 	} else {
-		g.enemyBeams = append(g.enemyBeams, b)
+		spawn(&g.world, &g.world.enemyBeams, b)
 	}
 }
 
 func (g *Game) AddBeamAnimation(b *BeamAnimation) {
-	g.beamAnimations = append(g.beamAnimations, b)
+	spawn(&g.world, &g.world.beamAnimations, b)
 }
 
 func (g *Game) AddAnimation(a *Animation) {
-	g.animations = append(g.animations, a)
+	spawn(&g.world, &g.world.animations, a)
 }
 
 func (g *Game) AddBlow(b *Blow, target config.Vector) {
-	g.blows = append(g.blows, b)
-	blowAnimation := NewAnimation(target, assets.BigBlowSpriteSheet, 1, 124, 128, false, "digBlow", 0)
-	g.AddAnimation(blowAnimation)
+	spawn(&g.world, &g.world.blows, b)
+	g.AddEffect(target, "bigBlow", 0)
+}
+
+func (g *Game) AddMeteor(m *Meteor) {
+	spawn(&g.world, &g.world.meteors, m)
+}
+
+func (g *Game) AddEnemy(e *Enemy) {
+	spawn(&g.world, &g.world.enemies, e)
 }
 
-func (g *Game) KillEnemy(i int) {
-	enemyBlow := NewAnimation(g.enemies[i].position, assets.EnemyBlowSpriteSheet, 1, 73, 75, false, "enemyBlow", 0)
-	g.AddAnimation(enemyBlow)
-	g.enemies = append(g.enemies[:i], g.enemies[i+1:]...)
-	g.score++
-	g.profile.credits += 10
+func (g *Game) AddItem(i *Item) {
+	spawn(&g.world, &g.world.items, i)
 }
 
-func (g *Game) IntersectProjectile(curPr *Projectile, i int) {
-	curPr.intercectAnimation.position = curPr.position
+func (g *Game) KillEnemy(e *Enemy) {
+	if !g.world.Despawn(e) {
+		return
+	}
+	g.AddEffect(e.position, "enemyBlow", 0)
+	g.events.Publish(EnemyKilled{Enemy: e})
+}
+
+func (g *Game) IntersectProjectile(curPr *Projectile) {
+	if !curPr.Alive() {
+		return
+	}
 	curPr.AddAnimation(g)
 	curPr.HP--
 	if curPr.HP <= 0 {
-		curPr.Destroy(g, i)
+		curPr.Destroy(g)
 	}
 }
 
 func (g *Game) Reset() {
-	g.meteors = nil
-	g.projectiles = nil
-	g.enemyProjectiles = nil
-	g.enemies = nil
-	g.items = nil
-	g.beams = nil
-	g.beamAnimations = nil
-	g.animations = nil
+	if g.playtest != nil {
+		g.playtest.stopPlaytest()
+		return
+	}
+	g.clearRun()
+	if g.finishedRun != nil {
+		g.scenes.Switch(g.nameEntry)
+		return
+	}
+	g.scenes.Switch(g.shipChoosingScreen)
+}
+
+// clearRun throws the current run away and prepares a fresh one.
+func (g *Game) clearRun() {
+	g.world.Clear()
 	g.score = 0
+	g.finishRecording()
+	g.choosenStartShip = nil
 	g.player = NewPlayer(g)
-	var newLevels = GenerateLevels()
-	g.curLevel = newLevels[0]
-	g.CurStage = &g.curLevel.Stages[0]
-	g.CurWave = &g.CurStage.Waves[0]
-	g.levels = newLevels
+	if !g.fixedSeed {
+		g.seed = newSeed()
+	}
+	g.generateLevels()
 	g.meteorSpawnTimer.Reset()
 	g.batchesSpawnTimer = config.NewTimer(g.levels[0].Stages[0].Waves[0].Batches[0].BatchSpawnTime)
 	g.itemSpawnTimer.Reset()
@@ -768,8 +419,45 @@ func (g *Game) Reset() {
 	g.started = false
 	g.menu = NewMainMenu(g)
 	g.profile = NewPlayerProfile(g)
-	g.bgImage = newLevels[0].BgImg
-	g.state = config.ShipChoosingWindow
+}
+
+// generateLevels builds the levels of the current seed and moves to the first
+// one.
+func (g *Game) generateLevels() {
+	g.rng = rand.New(rand.NewSource(g.seed))
+	g.levels = GenerateLevels(g.rng)
+	g.curLevel = g.levels[0]
+	g.CurStage = &g.curLevel.Stages[0]
+	g.CurWave = &g.CurStage.Waves[0]
+	g.bgImage = g.curLevel.BgImg
+}
+
+// StartRun puts a new ship of the chosen kind into the player's hands and
+// enters the game.
+func (g *Game) StartRun(ship *ShipDef) {
+	g.equipShip(ship)
+	g.applyMetaUpgrades(g.metaLevels())
+	g.resetRunTimers()
+	g.runTicks = 0
+	g.beginStage()
+	g.startRecording(g.choosenStartShip)
+	g.scenes.Switch(g.play)
+	g.publishWaveStarted()
+}
+
+func (g *Game) equipShip(def *ShipDef) {
+	g.choosenStartShip = def.newShip()
+	g.player.SetShip(g.choosenStartShip)
+}
+
+// resetRunTimers restarts the spawn and speed-up timers, so that a run only
+// depends on its seed and input.
+func (g *Game) resetRunTimers() {
+	g.meteorSpawnTimer = config.NewTimer(config.MeteorSpawnTime)
+	g.itemSpawnTimer = config.NewTimer(time.Second * 2)
+	g.batchesSpawnTimer = config.NewTimer(g.CurWave.Batches[0].BatchSpawnTime)
+	g.baseVelocity = config.BaseMeteorVelocity
+	g.velocityTimer.Reset()
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
@@ -0,0 +1,306 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	highScoresFileName = "highscores.json"
+	highScoresVersion  = 1
+	highScoresSize     = 10
+	maxNameLength      = 12
+)
+
+// HighScore is one finished run on the leaderboard.
+type HighScore struct {
+	Name     string        `json:"name"`
+	Score    int           `json:"score"`
+	Ship     string        `json:"ship"`
+	Level    int           `json:"level"`
+	Stage    int           `json:"stage"`
+	Wave     int           `json:"wave"`
+	Seed     int64         `json:"seed"`
+	Duration time.Duration `json:"duration"`
+	Date     time.Time     `json:"date"`
+}
+
+// HighScoreTable keeps the best runs, highest score first.
+type HighScoreTable struct {
+	Version int         `json:"version"`
+	Entries []HighScore `json:"entries"`
+}
+
+func LoadHighScores(path string) (*HighScoreTable, error) {
+	t := &HighScoreTable{Version: highScoresVersion}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(data, t)
+	if err != nil {
+		return &HighScoreTable{Version: highScoresVersion}, fmt.Errorf("high scores: %w", err)
+	}
+	if t.Version > highScoresVersion {
+		return &HighScoreTable{Version: highScoresVersion}, fmt.Errorf("high scores: unsupported version %d", t.Version)
+	}
+	t.Version = highScoresVersion
+	t.sort()
+	return t, nil
+}
+
+func (t *HighScoreTable) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (t *HighScoreTable) sort() {
+	slices.SortStableFunc(t.Entries, func(a, b HighScore) int {
+		return b.Score - a.Score
+	})
+	if len(t.Entries) > highScoresSize {
+		t.Entries = t.Entries[:highScoresSize]
+	}
+}
+
+// Qualifies tells whether a run with the given score makes it into the table.
+func (t *HighScoreTable) Qualifies(score int) bool {
+	if score <= 0 {
+		return false
+	}
+	return len(t.Entries) < highScoresSize || score > t.Entries[len(t.Entries)-1].Score
+}
+
+// Insert adds e behind every entry with the same score and returns its rank,
+// or -1 when it didn't make it.
+func (t *HighScoreTable) Insert(e HighScore) int {
+	idx := len(t.Entries)
+	for i, o := range t.Entries {
+		if e.Score > o.Score {
+			idx = i
+			break
+		}
+	}
+	if idx >= highScoresSize {
+		return -1
+	}
+	t.Entries = slices.Insert(t.Entries, idx, e)
+	t.sort()
+	return idx
+}
+
+// Filter returns the entries of the given ship, or all of them for "".
+func (t *HighScoreTable) Filter(ship string) []HighScore {
+	if ship == "" {
+		return t.Entries
+	}
+	var entries []HighScore
+	for _, e := range t.Entries {
+		if e.Ship == ship {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func loadHighScores() *HighScoreTable {
+	path, err := dataPath(highScoresFileName)
+	if err != nil {
+		log.Println(err)
+		return &HighScoreTable{Version: highScoresVersion}
+	}
+	t, err := LoadHighScores(path)
+	if err != nil {
+		log.Println(err)
+	}
+	return t
+}
+
+func (g *Game) saveHighScores() {
+	path, err := dataPath(highScoresFileName)
+	if err == nil {
+		err = g.highScores.Save(path)
+	}
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// endRun is called when a run ended by death or by clearing the last level,
+// right before the game is reset.
+func (g *Game) endRun() {
+	if !g.persist || g.choosenStartShip == nil {
+		return
+	}
+	g.deleteRunSave()
+	g.recordPilotRun()
+	if !g.highScores.Qualifies(g.score) {
+		return
+	}
+	g.finishedRun = &HighScore{
+		Score:    g.score,
+		Ship:     g.choosenStartShip.Name,
+		Level:    g.curLevel.LevelId + 1,
+		Stage:    g.CurStage.StageId + 1,
+		Wave:     g.CurWave.WaveId + 1,
+		Seed:     g.seed,
+		Duration: g.runDuration(),
+		Date:     time.Now(),
+	}
+}
+
+func (g *Game) runDuration() time.Duration {
+	return time.Duration(g.runTicks) * time.Second / time.Duration(ebiten.TPS())
+}
+
+// nameEntryScreen asks for the name of a run that made it into the table.
+type nameEntryScreen struct {
+	sceneHooks
+	game *Game
+	name textField
+}
+
+func (s *nameEntryScreen) Enter() {
+	s.name = textField{max: maxNameLength}
+	if s.game.pilot != nil {
+		s.name.Set(s.game.pilot.Name)
+	}
+}
+
+func (s *nameEntryScreen) Update() error {
+	g := s.game
+	if g.finishedRun == nil {
+		g.scenes.Switch(g.shipChoosingScreen)
+		return nil
+	}
+	s.name.Update(&g.input)
+	if g.input.IsKeyJustPressed(ebiten.KeyEnter) && len(s.name.text) > 0 {
+		run := *g.finishedRun
+		run.Name = s.name.String()
+		g.finishedRun = nil
+		g.highScoresScreen.highlight = g.highScores.Insert(run)
+		g.saveHighScores()
+		g.highScoresScreen.ship = ""
+		g.scenes.Switch(g.highScoresScreen)
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.finishedRun = nil
+		g.scenes.Switch(g.shipChoosingScreen)
+	}
+	return nil
+}
+
+func (s *nameEntryScreen) Draw(screen *ebiten.Image) {
+	g := s.game
+	g.DrawBg(screen)
+	if g.finishedRun == nil {
+		return
+	}
+	x := int(g.Options.ScreenWidth/2) - g.Options.ScreenXMenuShift
+	y := int(g.Options.ScreenHeight/2) - g.Options.ScreenYMenuShift
+	text.Draw(screen, "New high score!", g.Options.ScoreFont, x, y, color.RGBA{179, 14, 14, 255})
+	text.Draw(screen, fmt.Sprintf("Score: %d", g.finishedRun.Score), g.Options.InfoFont, x, y+g.Options.ScreenYMenuHeight, color.White)
+	text.Draw(screen, "Your name: "+s.name.String()+"_", g.Options.InfoFont, x, y+g.Options.ScreenYMenuHeight*2, color.White)
+	text.Draw(screen, "[enter] save  [esc] skip", g.Options.SmallFont, x, y+g.Options.ScreenYMenuHeight*3, color.RGBA{100, 100, 100, 255})
+}
+
+// highScoresScreen lists the table, left and right cycle the ship filter.
+type highScoresScreen struct {
+	sceneHooks
+	game      *Game
+	ship      string
+	highlight int
+}
+
+func (s *highScoresScreen) Exit() {
+	s.highlight = -1
+}
+
+func (s *highScoresScreen) Update() error {
+	g := s.game
+	filters := []string{""}
+	for _, ship := range Ships() {
+		filters = append(filters, ship.Name)
+	}
+	idx := max(slices.Index(filters, s.ship), 0)
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowRight) {
+		s.ship = filters[(idx+1)%len(filters)]
+		s.highlight = -1
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+		s.ship = filters[(idx+len(filters)-1)%len(filters)]
+		s.highlight = -1
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) || g.input.IsKeyJustPressed(ebiten.KeyEnter) || g.input.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		g.scenes.Switch(g.menu)
+	}
+	return nil
+}
+
+func (s *highScoresScreen) Draw(screen *ebiten.Image) {
+	g := s.game
+	g.DrawBg(screen)
+	w := g.Options.ScreenWidth
+	columns := []float64{0.04, 0.08, 0.24, 0.33, 0.50, 0.59, 0.67, 0.79}
+	lineHeight := g.Options.ScreenFontHeight * 3 / 2
+	y := g.Options.ScreenYMenuHeight * 2
+
+	filter := "All ships"
+	if s.ship != "" {
+		filter = s.ship
+	}
+	text.Draw(screen, "High scores", g.Options.ScoreFont, int(w*columns[0]), y, color.White)
+	text.Draw(screen, "< "+filter+" >", g.Options.InfoFont, int(w*columns[4]), y, color.White)
+	y += g.Options.ScreenYMenuHeight
+
+	header := []string{"#", "Name", "Score", "Ship", "Reached", "Time", "Date", "Seed"}
+	for i, h := range header {
+		text.Draw(screen, h, g.Options.SmallFont, int(w*columns[i]), y, color.RGBA{100, 100, 100, 255})
+	}
+	entries := g.highScores.Filter(s.ship)
+	if len(entries) == 0 {
+		text.Draw(screen, "No runs yet", g.Options.SmallFont, int(w*columns[1]), y+lineHeight, color.White)
+	}
+	for i, e := range entries {
+		y += lineHeight
+		c := color.RGBA{255, 255, 255, 255}
+		if s.ship == "" && i == s.highlight {
+			c = color.RGBA{179, 14, 14, 255}
+		}
+		row := []string{
+			fmt.Sprint(i + 1),
+			e.Name,
+			fmt.Sprint(e.Score),
+			e.Ship,
+			fmt.Sprintf("%d-%d-%d", e.Level, e.Stage, e.Wave),
+			e.Duration.Round(time.Second).String(),
+			e.Date.Format("2006-01-02"),
+			fmt.Sprint(e.Seed),
+		}
+		for j, v := range row {
+			text.Draw(screen, v, g.Options.SmallFont, int(w*columns[j]), y, c)
+		}
+	}
+	text.Draw(screen, "[left/right] ship  [esc] back", g.Options.SmallFont, int(w*columns[0]), int(g.Options.ScreenHeight)-g.Options.ScreenYMenuHeight, color.RGBA{100, 100, 100, 255})
+}
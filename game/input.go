@@ -0,0 +1,96 @@
+package game
+
+import (
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+var trackedMouseButtons = []ebiten.MouseButton{
+	ebiten.MouseButtonLeft,
+	ebiten.MouseButtonRight,
+	ebiten.MouseButtonMiddle,
+}
+
+// InputState is everything the game reads from the player during one tick.
+type InputState struct {
+	Keys    []ebiten.Key
+	Buttons []ebiten.MouseButton
+	CursorX int
+	CursorY int
+	// Unfocused is set while the game window does not have the focus
+	Unfocused bool
+}
+
+// InputSource produces the input for a given tick. The live game reads the
+// keyboard and mouse, tests, bots and replays can feed anything else.
+type InputSource interface {
+	Next(tick int) InputState
+}
+
+// InputFunc adapts a plain function to the InputSource interface.
+type InputFunc func(tick int) InputState
+
+func (f InputFunc) Next(tick int) InputState {
+	return f(tick)
+}
+
+type ebitenInput struct {
+	keys []ebiten.Key
+}
+
+func (e *ebitenInput) Next(tick int) InputState {
+	e.keys = inpututil.AppendPressedKeys(e.keys[:0])
+	s := InputState{
+		Keys: slices.Clone(e.keys),
+	}
+	s.CursorX, s.CursorY = ebiten.CursorPosition()
+	s.Unfocused = !ebiten.IsFocused()
+	for _, b := range trackedMouseButtons {
+		if ebiten.IsMouseButtonPressed(b) {
+			s.Buttons = append(s.Buttons, b)
+		}
+	}
+	return s
+}
+
+// Input keeps the current and the previous tick state so "just pressed"
+// checks work the same way for every input source.
+type Input struct {
+	cur  InputState
+	prev InputState
+}
+
+func (in *Input) Update(s InputState) {
+	in.prev = in.cur
+	in.cur = s
+}
+
+func (in *Input) State() InputState {
+	return in.cur
+}
+
+func (in *Input) IsKeyPressed(k ebiten.Key) bool {
+	return slices.Contains(in.cur.Keys, k)
+}
+
+func (in *Input) IsKeyJustPressed(k ebiten.Key) bool {
+	return slices.Contains(in.cur.Keys, k) && !slices.Contains(in.prev.Keys, k)
+}
+
+func (in *Input) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return slices.Contains(in.cur.Buttons, b)
+}
+
+func (in *Input) IsMouseButtonJustPressed(b ebiten.MouseButton) bool {
+	return slices.Contains(in.cur.Buttons, b) && !slices.Contains(in.prev.Buttons, b)
+}
+
+func (in *Input) Unfocused() bool {
+	return in.cur.Unfocused
+}
+
+func (in *Input) CursorPosition() (int, int) {
+	return in.cur.CursorX, in.cur.CursorY
+}
@@ -1,15 +1,17 @@
 package game
 
 import (
-	"astrogame/assets"
 	"astrogame/config"
 	"astrogame/objects"
 	"image"
+	"log"
+	"slices"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type Item struct {
+	entity
 	game       *Game
 	position   config.Vector
 	target     config.Vector
@@ -31,9 +33,6 @@ func NewItem(g *Game, target config.Vector, pos config.Vector, itemType *config.
 		Y: normalizedDirection.Y * itemType.Velocity,
 	}
 
-	modSprite := objects.ScaleImg(itemType.Sprite, 0.5)
-	itemType.Sprite = modSprite
-
 	i := &Item{
 		game:     g,
 		position: pos,
@@ -44,7 +43,18 @@ func NewItem(g *Game, target config.Vector, pos config.Vector, itemType *config.
 	return i
 }
 
-func (i *Item) CollideWithPlayer(p *Player) {
+// newItemWeapon makes the weapon an item gives, items naming a weapon missing
+// from the registry give nothing.
+func newItemWeapon(name string, p *Player) *Weapon {
+	weapon := NewWeapon(name, p)
+	if weapon == nil {
+		log.Printf("item: unknown weapon %q", name)
+	}
+	return weapon
+}
+
+// ApplyTo gives the effect of the item to the player.
+func (i *Item) ApplyTo(p *Player) {
 	if i.itemType.AmmoType != nil {
 		for _, w := range p.weapons {
 			if w.projectile.wType.WeaponName == i.itemType.AmmoType.WeaponName {
@@ -52,30 +62,41 @@ func (i *Item) CollideWithPlayer(p *Player) {
 			}
 		}
 	} else if i.itemType.WeaponType != nil {
+		weapon := newItemWeapon(i.itemType.WeaponType.WeaponName, p)
+		if weapon == nil {
+			return
+		}
 		persist := false
 		for _, w := range p.weapons {
-			if w.projectile.wType.WeaponName == i.itemType.WeaponType.WeaponName {
-				weapon := NewWeapon(i.itemType.WeaponType.WeaponName, p)
+			if w.projectile.wType.WeaponName == weapon.projectile.wType.WeaponName {
 				w.ammo += weapon.ammo
 				persist = true
 			}
 
 		}
 		if !persist {
-			p.weapons = append(p.weapons, NewWeapon(i.itemType.WeaponType.WeaponName, p))
+			p.weapons = append(p.weapons, weapon)
 		}
 	} else if i.itemType.SecondWeaponType != nil {
+		weapon := newItemWeapon(i.itemType.SecondWeaponType.WeaponName, p)
+		if weapon == nil {
+			return
+		}
 		persist := false
 		for _, w := range p.secondaryWeapons {
-			if w.projectile.wType.WeaponName == i.itemType.SecondWeaponType.WeaponName {
-				weapon := NewWeapon(i.itemType.SecondWeaponType.WeaponName, p)
+			if w.projectile.wType.WeaponName == weapon.projectile.wType.WeaponName {
 				w.ammo += weapon.ammo
 				persist = true
 			}
 
 		}
-		if !persist {
-			p.secondaryWeapons = append(p.secondaryWeapons, NewWeapon(i.itemType.SecondWeaponType.WeaponName, p))
+		if !persist && len(p.secondaryWeapons) >= p.params.SecondarySlots {
+			// Every slot is taken, so the new weapon replaces the selected one
+			idx := max(slices.Index(p.secondaryWeapons, p.curSecondaryWeapon), 0)
+			p.secondaryWeapons[idx] = weapon
+			p.curSecondaryWeapon = weapon
+		} else if !persist {
+			p.secondaryWeapons = append(p.secondaryWeapons, weapon)
 			if p.curSecondaryWeapon == nil {
 				p.curSecondaryWeapon = p.secondaryWeapons[0]
 			}
@@ -86,21 +107,7 @@ func (i *Item) CollideWithPlayer(p *Player) {
 		if p.shield != nil {
 			p.shield.HP += i.itemType.ShieldType.HP
 		} else {
-			w := p.sprite.Bounds().Dx()
-			h := p.sprite.Bounds().Dy()
-			px, py := p.position.X-float64(w)/2, p.position.Y-float64(h)/2
-			p.shield = &Shield{
-				position: p.position,
-				HP:       i.itemType.ShieldType.HP,
-				sprite:   i.itemType.ShieldType.Sprite,
-			}
-			animationPos := config.Vector{
-				X: px,
-				Y: py,
-			}
-			shieldAnimation := NewAnimation(animationPos, assets.ShieldSpriteSheet, 1, 192, 192, true, "shield", 0)
-			p.animations = append(p.animations, shieldAnimation)
-			p.game.AddAnimation(shieldAnimation)
+			p.addShield(i.itemType.ShieldType.HP, i.itemType.ShieldType.Sprite)
 		}
 	}
 }
@@ -139,11 +146,11 @@ func (i *Item) Update() {
 }
 
 func (i *Item) Draw(screen *ebiten.Image) {
-	objects.RotateAndTranslateObject(i.rotation, i.itemType.Sprite, screen, i.position.X, i.position.Y)
+	objects.RotateAndTranslateObject(i.rotation, i.sprite(), screen, i.position.X, i.position.Y)
 }
 
 func (i *Item) Collider() image.Rectangle {
-	bounds := i.itemType.Sprite.Bounds()
+	bounds := i.sprite().Bounds()
 	return image.Rectangle{
 		Min: image.Point{
 			X: int(i.position.X),
@@ -155,3 +162,8 @@ func (i *Item) Collider() image.Rectangle {
 		},
 	}
 }
+
+// sprite is the item sprite scaled for the current resolution.
+func (i *Item) sprite() *ebiten.Image {
+	return i.game.sprites.Scaled(i.itemType.Sprite, i.game.Options.ItemScale)
+}
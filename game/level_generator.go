@@ -5,40 +5,50 @@ import (
 	"astrogame/config"
 	"astrogame/objects"
 	"fmt"
+	"math/rand"
 )
 
+// generatedLevelCount is how many levels a run has when the campaign doesn't
+// add any.
+const generatedLevelCount = 10
+
 type levelTemplatesGen struct {
 	lvls []*config.LevelTemplate
 }
 
-func GenerateLevels() []*config.Level {
+func GenerateLevels(r *rand.Rand) []*config.Level {
 	var levels []*config.Level
-	lGen := GenerateLevelStructure()
-	lGen.DecorateLevels()
+	lGen := GenerateLevelStructure(r)
+	lGen.DecorateLevels(r)
 	for _, l := range lGen.lvls {
-		levels = append(levels, l.ToLevel())
+		levels = append(levels, l.ToLevel(r))
+	}
+	levels = currentCampaign().apply(levels)
+	for i, l := range levels {
+		l.LevelId = i
+		l.Number = i + 1
 	}
 	return levels
 }
-func (lvlTpl levelTemplatesGen) DecorateLevels() {
+func (lvlTpl levelTemplatesGen) DecorateLevels(r *rand.Rand) {
 	for i, l := range lvlTpl.lvls {
 		for k, s := range l.Stages {
 			for j, w := range s.Waves {
 				for _, b := range w.Batches {
-					costLvlIdx := i + 1
-					costStageIdx := k + 1
-					costWaveIdx := j + 1
-					cost := costLvlIdx*20 + 10*costStageIdx + costWaveIdx*2
+					cost := config.WaveCost(i, k, j)
 					for _, e := range b.Enemies {
-						DecorateEnemyTemplate(e, i, k, j, cost, &lvlTpl)
+						DecorateEnemyTemplate(e, i, k, j, cost, &lvlTpl, r)
 					}
 				}
 			}
 		}
+		lastStage := len(l.Stages) - 1
+		lastWave := len(l.Stages[lastStage].Waves) - 1
+		l.Boss = config.NewBoss(r, config.BossBudget(config.WaveCost(i, lastStage, lastWave)))
 	}
 }
 
-func DecorateEnemyTemplate(e *config.EnemyTemplate, l int, s int, w int, cost int, lTpl *levelTemplatesGen) {
+func DecorateEnemyTemplate(e *config.EnemyTemplate, l int, s int, w int, cost int, lTpl *levelTemplatesGen, r *rand.Rand) {
 	// thirdPart := s / 3
 	// wavesThirdPart := w / 3
 	// costLvlIdx := l
@@ -135,7 +145,7 @@ func DecorateEnemyTemplate(e *config.EnemyTemplate, l int, s int, w int, cost in
 	e.CurCost = cost
 	bodyAdded := false
 	weaponAdded := false
-	weaponMinCost := 6
+	economy := config.Economy()
 	eBodies := config.NewEnemyBodies()
 	eWeapons := config.NewWeaponTypes()
 	for {
@@ -143,14 +153,14 @@ func DecorateEnemyTemplate(e *config.EnemyTemplate, l int, s int, w int, cost in
 			break
 		}
 		if !bodyAdded {
-			randBodyCount := objects.RandInt(0, len(eBodies)-1)
+			randBodyCount := r.Intn(len(eBodies))
 			e.SetBody(eBodies[randBodyCount])
 			if e.StartHP != 0 {
 				bodyAdded = true
 			}
 		}
-		if bodyAdded && e.CurCost >= weaponMinCost && !weaponAdded {
-			randWeaponCount := objects.RandInt(0, len(eWeapons)-1)
+		if bodyAdded && e.CurCost >= economy.WeaponMinCost && !weaponAdded {
+			randWeaponCount := r.Intn(len(eWeapons))
 			e.SetWeapon(eWeapons[randWeaponCount])
 			if e.WeaponType != nil {
 				weaponAdded = true
@@ -161,9 +171,9 @@ func DecorateEnemyTemplate(e *config.EnemyTemplate, l int, s int, w int, cost in
 			e.AddVelocity()
 		}
 		if bodyAdded && weaponAdded {
-			velocity := 12.0
+			velocity := economy.ProjectileVelocityStep
 			if config.TargetTypePlayer == e.WeaponType.TargetType {
-				velocity = 0.1
+				velocity = economy.GuidedProjectileVelocityStep
 			}
 			e.AddWeaponProjectileDamage()
 			e.AddWeaponProjectileVelocity(velocity)
@@ -175,9 +185,9 @@ func DecorateEnemyTemplate(e *config.EnemyTemplate, l int, s int, w int, cost in
 		}
 	}
 }
-func GenerateLevelStructure() levelTemplatesGen {
+func GenerateLevelStructure(r *rand.Rand) levelTemplatesGen {
 	var structure levelTemplatesGen
-	for l := 0; l < 10; l++ {
+	for l := 0; l < generatedLevelCount; l++ {
 		var stageCountLLimit int
 		var stageCountRLimit int
 		if l <= 3 {
@@ -190,8 +200,8 @@ func GenerateLevelStructure() levelTemplatesGen {
 			stageCountLLimit = 5
 			stageCountRLimit = 8
 		}
-		randStageCount := objects.RandInt(stageCountLLimit, stageCountRLimit)
-		stages := generateStages(l, randStageCount)
+		randStageCount := objects.RandInt(r, stageCountLLimit, stageCountRLimit)
+		stages := generateStages(r, l, randStageCount)
 		var levels config.LevelTemplate
 		levels.Stages = append(levels.Stages, stages...)
 		structure.lvls = append(structure.lvls, &levels)
@@ -213,15 +223,15 @@ func GenerateLevelStructure() levelTemplatesGen {
 					batchCountLLimit = 4
 					batchCountRLimit = 7
 				}
-				randWaveCount := objects.RandInt(batchCountLLimit, batchCountRLimit)
-				w.Batches = generateBatches(i, randWaveCount)
+				randWaveCount := objects.RandInt(r, batchCountLLimit, batchCountRLimit)
+				w.Batches = generateBatches(r, i, randWaveCount)
 			}
 		}
 	}
 	return structure
 }
 
-func generateStages(l int, count int) []*config.StageTemplate {
+func generateStages(r *rand.Rand, l int, count int) []*config.StageTemplate {
 	var stages []*config.StageTemplate
 	thirdPart := count / 3
 	for w := 0; w < count; w++ {
@@ -261,12 +271,12 @@ func generateStages(l int, count int) []*config.StageTemplate {
 				waveCountRLimit = 18
 			}
 		}
-		randWaveCount := objects.RandInt(waveCountLLimit, waveCountRLimit)
-		randItemCount := objects.RandInt(waveCountLLimit/2, waveCountRLimit/2)
+		randWaveCount := objects.RandInt(r, waveCountLLimit, waveCountRLimit)
+		randItemCount := objects.RandInt(r, waveCountLLimit/2, waveCountRLimit/2)
 		var stage config.StageTemplate
 		waves := generateWaves(randWaveCount)
 		stage.Waves = waves
-		items := generateItems(l, randItemCount)
+		items := generateItems(r, l, randItemCount)
 		stage.Items = items
 		stages = append(stages, &stage)
 	}
@@ -282,7 +292,7 @@ func generateWaves(count int) []*config.WaveTemplate {
 	return waves
 }
 
-func generateBatches(l int, count int) []*config.BatchTemplate {
+func generateBatches(r *rand.Rand, l int, count int) []*config.BatchTemplate {
 	var batches []*config.BatchTemplate
 	for w := 0; w < count; w++ {
 		var batch config.BatchTemplate
@@ -302,7 +312,7 @@ func generateBatches(l int, count int) []*config.BatchTemplate {
 			enemyBatchIdx = 8
 			minEnemyCount = 4
 		}
-		randEnemyCount := objects.RandInt(minEnemyCount, enemyBatchIdx)
+		randEnemyCount := objects.RandInt(r, minEnemyCount, enemyBatchIdx)
 		b.Enemies = generateEnemies(randEnemyCount)
 	}
 	return batches
@@ -317,11 +327,11 @@ func generateEnemies(count int) []*config.EnemyTemplate {
 	return enemies
 }
 
-func generateItems(l int, count int) []*config.ItemTemplate {
+func generateItems(r *rand.Rand, l int, count int) []*config.ItemTemplate {
 	var items []*config.ItemTemplate
 	for w := 0; w < count; w++ {
 		itemsForLvl := config.NewItemTypes(l)
-		itemRandNumber := objects.RandInt(0, len(itemsForLvl)-1)
+		itemRandNumber := objects.RandInt(r, 0, len(itemsForLvl)-1)
 		items = append(items, itemsForLvl[itemRandNumber])
 	}
 	return items
@@ -1,14 +1,13 @@
 package game
 
 import (
-	"astrogame/config"
 	"fmt"
 	"image"
 	"image/color"
+	"log"
 	"sort"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 )
 
@@ -17,6 +16,7 @@ type Menu interface {
 	Draw(g *Game, Items []*MenuItem, screen *ebiten.Image)
 }
 type MainMenu struct {
+	sceneHooks
 	Game  *Game
 	Items []*MenuItem
 }
@@ -33,8 +33,9 @@ type MenuItem struct {
 func StartGame(g *Game) error {
 	if g.started {
 		g.Reset()
+		return nil
 	}
-	g.state = config.ShipChoosingWindow
+	g.scenes.Switch(g.shipChoosingScreen)
 	return nil
 }
 
@@ -43,7 +44,19 @@ func ExitGame(g *Game) error {
 }
 
 func ContinueGame(g *Game) error {
-	g.state = config.InGame
+	if g.started {
+		g.scenes.Switch(g.play)
+		return nil
+	}
+	err := g.ContinueSavedRun()
+	if err != nil {
+		log.Println(err)
+		for _, i := range g.menu.Items {
+			if i.Label == "Continue game" {
+				i.Active = false
+			}
+		}
+	}
 	return nil
 }
 
@@ -66,21 +79,77 @@ func NewMainMenu(g *Game) *MainMenu {
 				Pos:     1,
 			},
 			{
-				Label: "Options",
+				Label: "High scores",
 				Action: func(g *Game) error {
-					g.state = config.Options
+					g.scenes.Switch(g.highScoresScreen)
 					return nil
 				},
 				Active:  true,
 				Choosen: false,
 				Pos:     2,
 			},
+			{
+				Label: "Pilots",
+				Action: func(g *Game) error {
+					g.scenes.Switch(g.pilotsScreen)
+					return nil
+				},
+				Active:  true,
+				Choosen: false,
+				Pos:     3,
+			},
+			{
+				Label: "Hangar",
+				Action: func(g *Game) error {
+					g.scenes.Switch(g.hangar)
+					return nil
+				},
+				Active:  false,
+				Choosen: false,
+				Pos:     4,
+			},
+			{
+				Label: "Level editor",
+				Action: func(g *Game) error {
+					// The editor's play-tests replace the run, so a run in
+					// progress is saved for later first
+					if g.started {
+						g.saveRun()
+						g.clearRun()
+					}
+					g.scenes.Switch(g.editor)
+					return nil
+				},
+				Active:  true,
+				Choosen: false,
+				Pos:     5,
+			},
+			{
+				Label: "Mods",
+				Action: func(g *Game) error {
+					g.scenes.Switch(g.mods)
+					return nil
+				},
+				Active:  true,
+				Choosen: false,
+				Pos:     6,
+			},
+			{
+				Label: "Options",
+				Action: func(g *Game) error {
+					g.scenes.Push(g.optionsMenu)
+					return nil
+				},
+				Active:  true,
+				Choosen: false,
+				Pos:     7,
+			},
 			{
 				Label:   "Exit game",
 				Action:  ExitGame,
 				Active:  true,
 				Choosen: false,
-				Pos:     3,
+				Pos:     8,
 			},
 		},
 	}
@@ -92,9 +161,23 @@ func NewMainMenu(g *Game) *MainMenu {
 			Max: image.Point{X: int(g.Options.ScreenWidth/2) - g.Options.ScreenXMenuShift + chars*g.Options.ScreenFontWidth, Y: int(g.Options.ScreenHeight/2) - g.Options.ScreenYMenuShift + g.Options.ScreenYMenuHeight*idx + g.Options.ScreenFontHeight},
 		}
 	}
+	MainMenu.updateItems()
 	return &MainMenu
 }
 
+// updateItems unlocks "Continue game" while a run is in progress or saved,
+// and the hangar while the pilot plays with meta progression.
+func (m *MainMenu) updateItems() {
+	for _, i := range m.Items {
+		switch i.Label {
+		case "Continue game":
+			i.Active = m.Game.started || m.Game.persist && m.Game.hasRunSave()
+		case "Hangar":
+			i.Active = m.Game.metaEnabled()
+		}
+	}
+}
+
 func (m *MainMenu) Update() error {
 	return MenuUpdate(m.Game, m.Items)
 }
@@ -126,7 +209,7 @@ func MenuDraw(g *Game, Items []*MenuItem, screen *ebiten.Image) {
 
 func MenuUpdate(g *Game, Items []*MenuItem) error {
 	// Keyboard arrows input handling
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowDown) {
 		for i := 0; i < len(Items); i++ {
 			if Items[i].Choosen && i < len(Items)-1 {
 				if Items[i+1].Active {
@@ -141,7 +224,7 @@ func MenuUpdate(g *Game, Items []*MenuItem) error {
 			continue
 		}
 	}
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowUp) {
 		for i := len(Items) - 1; i >= 0; i-- {
 			if Items[i].Choosen && i > 0 {
 				if Items[i-1].Active {
@@ -158,7 +241,7 @@ func MenuUpdate(g *Game, Items []*MenuItem) error {
 	}
 
 	// Choose menu item
-	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+	if g.input.IsKeyJustPressed(ebiten.KeyEnter) {
 		for i := 0; i < len(Items); i++ {
 			if Items[i].Choosen {
 				err := Items[i].Action(g)
@@ -171,7 +254,7 @@ func MenuUpdate(g *Game, Items []*MenuItem) error {
 	}
 
 	// Mouse hover on menu items
-	mouseX, mouseY := ebiten.CursorPosition()
+	mouseX, mouseY := g.input.CursorPosition()
 	for i, menuItem := range Items {
 		if mouseX >= menuItem.vector.Min.X && mouseX <= menuItem.vector.Max.X && mouseY >= menuItem.vector.Min.Y && mouseY <= menuItem.vector.Max.Y && Items[i].Active {
 			Items[i].Choosen = false
@@ -188,7 +271,7 @@ func MenuUpdate(g *Game, Items []*MenuItem) error {
 	}
 
 	// Mouse click on menu items
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+	if g.input.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		for i, menuItem := range Items {
 			if mouseX >= menuItem.vector.Min.X && mouseX <= menuItem.vector.Max.X && mouseY >= menuItem.vector.Min.Y && mouseY <= menuItem.vector.Max.Y && Items[i].Active {
 				err := Items[i].Action(g)
@@ -201,7 +284,7 @@ func MenuUpdate(g *Game, Items []*MenuItem) error {
 	}
 
 	// Return to game if started
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && g.started {
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) && g.started {
 		err := ContinueGame(g)
 		if err != nil {
 			return err
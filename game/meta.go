@@ -0,0 +1,180 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"astrogame/config"
+)
+
+const (
+	medalsPerKill  = 1
+	medalsPerStage = 10
+	medalsPerLevel = 25
+)
+
+// MetaProgress is what a pilot keeps from run to run.
+type MetaProgress struct {
+	Medals   int            `json:"medals"`
+	Upgrades map[string]int `json:"upgrades"`
+}
+
+// metaUpgrade is a permanent upgrade bought with medals in the hangar. It is
+// applied to the player of every new run.
+type metaUpgrade struct {
+	id    string
+	label string
+	max   int
+	cost  func(level int) int
+	apply func(p *Player, level int)
+}
+
+var startWeapons = []string{config.LightRocket, config.MachineGun, config.DoubleLightRocket}
+
+var metaUpgrades = []metaUpgrade{
+	{
+		id:    "startHP",
+		label: "Reinforced hull: +2 starting HP",
+		max:   5,
+		cost:  func(level int) int { return 20 * (level + 1) },
+		apply: func(p *Player, level int) {
+			p.params.HP += 2 * level
+		},
+	},
+	{
+		id:    "startWeapon",
+		label: "Armory: extra starting weapon",
+		max:   len(startWeapons),
+		cost:  func(level int) int { return 50 * (level + 1) },
+		apply: func(p *Player, level int) {
+			for _, name := range startWeapons[:level] {
+				if w := NewWeapon(name, p); w != nil {
+					p.weapons = append(p.weapons, w)
+				}
+			}
+		},
+	},
+	{
+		id:    "secondarySlots",
+		label: "Hardpoints: +1 secondary weapon slot",
+		max:   2,
+		cost:  func(level int) int { return 40 * (level + 1) },
+		apply: func(p *Player, level int) {
+			p.params.SecondarySlots += level
+		},
+	},
+}
+
+// metaEnabled tells whether the current pilot plays with meta progression.
+func (g *Game) metaEnabled() bool {
+	return g.pilot != nil && g.settings.MetaProgression
+}
+
+// metaLevels returns the permanent upgrades a new run starts with.
+func (g *Game) metaLevels() map[string]int {
+	if g.replayUpgrades != nil {
+		return g.replayUpgrades
+	}
+	if !g.metaEnabled() {
+		return nil
+	}
+	return g.pilot.Meta.Upgrades
+}
+
+func (g *Game) applyMetaUpgrades(levels map[string]int) {
+	for _, u := range metaUpgrades {
+		level := min(levels[u.id], u.max)
+		if level > 0 {
+			u.apply(g.player, level)
+		}
+	}
+}
+
+func (g *Game) awardMedals(n int) {
+	if g.metaEnabled() {
+		g.pilot.Meta.Medals += n
+	}
+}
+
+func (g *Game) subscribeMeta() {
+	Subscribe(g.events, func(e EnemyKilled) {
+		g.awardMedals(medalsPerKill)
+	})
+	Subscribe(g.events, func(e StageCleared) {
+		g.awardMedals(medalsPerStage)
+		g.savePilots()
+	})
+	Subscribe(g.events, func(e LevelCleared) {
+		g.awardMedals(medalsPerLevel)
+		g.savePilots()
+	})
+}
+
+// hangarScreen sells the permanent upgrades between runs.
+type hangarScreen struct {
+	sceneHooks
+	game *Game
+	sel  int
+}
+
+func (s *hangarScreen) Update() error {
+	g := s.game
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		s.sel = (s.sel + 1) % len(metaUpgrades)
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		s.sel = (s.sel + len(metaUpgrades) - 1) % len(metaUpgrades)
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyEnter) && g.metaEnabled() {
+		meta := &g.pilot.Meta
+		u := metaUpgrades[s.sel]
+		level := meta.Upgrades[u.id]
+		if level < u.max && meta.Medals >= u.cost(level) {
+			meta.Medals -= u.cost(level)
+			if meta.Upgrades == nil {
+				meta.Upgrades = map[string]int{}
+			}
+			meta.Upgrades[u.id] = level + 1
+			g.savePilots()
+		}
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) || g.input.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		g.scenes.Switch(g.menu)
+	}
+	return nil
+}
+
+func (s *hangarScreen) Draw(screen *ebiten.Image) {
+	g := s.game
+	g.DrawBg(screen)
+	if !g.metaEnabled() {
+		return
+	}
+	meta := g.pilot.Meta
+	x := int(g.Options.ScreenWidth/2) - g.Options.ScreenXMenuShift*2
+	y := g.Options.ScreenYMenuHeight * 2
+	text.Draw(screen, "Hangar", g.Options.ScoreFont, x, y, color.White)
+	y += g.Options.ScreenYMenuHeight
+	text.Draw(screen, fmt.Sprintf("Medals: %d", meta.Medals), g.Options.InfoFont, x, y, color.White)
+	y += g.Options.ScreenYMenuHeight
+	for i, u := range metaUpgrades {
+		level := meta.Upgrades[u.id]
+		c := color.RGBA{255, 255, 255, 255}
+		if i == s.sel {
+			c = color.RGBA{179, 14, 14, 255}
+		}
+		price := fmt.Sprintf("%d medals", u.cost(level))
+		if level >= u.max {
+			price = "maxed"
+		} else if meta.Medals < u.cost(level) && i != s.sel {
+			c = color.RGBA{100, 100, 100, 255}
+		}
+		text.Draw(screen, u.label, g.Options.InfoFont, x, y, c)
+		text.Draw(screen, fmt.Sprintf("level %d/%d  %s", level, u.max, price), g.Options.SmallFont, x, y+g.Options.ScreenFontHeight, color.RGBA{100, 100, 100, 255})
+		y += g.Options.ScreenYMenuHeight * 3 / 2
+	}
+	text.Draw(screen, "[enter] buy  [esc] back  upgrades apply to new runs", g.Options.SmallFont, x, int(g.Options.ScreenHeight)-g.Options.ScreenYMenuHeight, color.RGBA{100, 100, 100, 255})
+}
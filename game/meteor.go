@@ -2,7 +2,6 @@ package game
 
 import (
 	"image"
-	"math/rand"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -17,6 +16,7 @@ const (
 )
 
 type Meteor struct {
+	entity
 	position      config.Vector
 	rotation      float64
 	movement      config.Vector
@@ -31,11 +31,11 @@ func NewMeteor(baseVelocity float64, g *Game) *Meteor {
 	}
 
 	pos := config.Vector{
-		X: g.Options.ScreenWidth * rand.Float64(),
+		X: g.Options.ScreenWidth * g.rng.Float64(),
 		Y: -10,
 	}
 
-	velocity := baseVelocity + rand.Float64()*1.5
+	velocity := baseVelocity + g.rng.Float64()*1.5
 
 	direction := config.Vector{
 		X: target.X - pos.X,
@@ -48,12 +48,12 @@ func NewMeteor(baseVelocity float64, g *Game) *Meteor {
 		Y: normalizedDirection.Y * velocity,
 	}
 
-	modSprite := objects.ScaleImg(assets.MeteorSprites[rand.Intn(len(assets.MeteorSprites))], float64(objects.RandInt(5, 8))/10)
+	modSprite := g.sprites.Scaled(assets.MeteorSprites[g.rng.Intn(len(assets.MeteorSprites))], float64(objects.RandInt(g.rng, 5, 8))/10)
 
 	m := &Meteor{
 		position:      pos,
 		movement:      movement,
-		rotationSpeed: rotationSpeedMin + rand.Float64()*(rotationSpeedMax-rotationSpeedMin),
+		rotationSpeed: rotationSpeedMin + g.rng.Float64()*(rotationSpeedMax-rotationSpeedMin),
 		sprite:        modSprite,
 	}
 	return m
@@ -0,0 +1,132 @@
+package game
+
+import (
+	"image/color"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"astrogame/assets"
+)
+
+// modsScreen turns the installed mod packs on and off and orders them. The
+// first pack wins when several replace the same file. Changes are applied on
+// the next start, as assets are loaded before anything else.
+type modsScreen struct {
+	sceneHooks
+	game    *Game
+	packs   []assets.Pack
+	sel     int
+	changed bool
+	status  string
+}
+
+func (s *modsScreen) Enter() {
+	var err error
+	s.packs, err = assets.Packs()
+	s.status = ""
+	if err != nil {
+		s.status = err.Error()
+	}
+	s.sel = max(min(s.sel, len(s.packs)-1), 0)
+}
+
+func (s *modsScreen) Update() error {
+	g := s.game
+	if len(s.packs) > 0 {
+		if g.input.IsKeyJustPressed(ebiten.KeyArrowDown) {
+			s.sel = (s.sel + 1) % len(s.packs)
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyArrowUp) {
+			s.sel = (s.sel + len(s.packs) - 1) % len(s.packs)
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyEnter) || g.input.IsKeyJustPressed(ebiten.KeySpace) {
+			s.packs[s.sel].Enabled = !s.packs[s.sel].Enabled
+			s.save()
+		}
+		// Move the selected pack up or down the load order
+		if g.input.IsKeyJustPressed(ebiten.KeyW) && s.sel > 0 {
+			s.packs[s.sel-1], s.packs[s.sel] = s.packs[s.sel], s.packs[s.sel-1]
+			s.sel--
+			s.save()
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyS) && s.sel < len(s.packs)-1 {
+			s.packs[s.sel+1], s.packs[s.sel] = s.packs[s.sel], s.packs[s.sel+1]
+			s.sel++
+			s.save()
+		}
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) || g.input.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		g.scenes.Switch(g.menu)
+	}
+	return nil
+}
+
+func (s *modsScreen) save() {
+	err := assets.SavePacks(s.packs)
+	if err != nil {
+		s.status = err.Error()
+		return
+	}
+	var enabled []string
+	for _, p := range s.packs {
+		if p.Enabled {
+			enabled = append(enabled, p.Name)
+		}
+	}
+	s.changed = !slices.Equal(enabled, assets.EnabledPacks())
+	s.status = ""
+}
+
+func (s *modsScreen) Draw(screen *ebiten.Image) {
+	g := s.game
+	g.DrawBg(screen)
+	grey := color.RGBA{100, 100, 100, 255}
+	red := color.RGBA{179, 14, 14, 255}
+	lineHeight := g.Options.ScreenFontHeight * 3 / 2
+	x := int(g.Options.ScreenWidth * 0.04)
+	y := g.Options.ScreenYMenuHeight * 2
+	text.Draw(screen, "Mods", g.Options.ScoreFont, x, y, color.White)
+	y += g.Options.ScreenYMenuHeight
+
+	if len(s.packs) == 0 {
+		dir, _ := assets.ModsDir()
+		text.Draw(screen, "No mod packs installed. Put a pack folder in", g.Options.SmallFont, x, y, color.White)
+		text.Draw(screen, dir, g.Options.SmallFont, x, y+lineHeight, color.White)
+		y += lineHeight * 2
+	}
+	for i, p := range s.packs {
+		c := color.RGBA{255, 255, 255, 255}
+		if i == s.sel {
+			c = red
+		} else if !p.Enabled {
+			c = grey
+		}
+		state := "[ ] "
+		if p.Enabled {
+			state = "[x] "
+		}
+		text.Draw(screen, state+p.Name, g.Options.InfoFont, x, y, c)
+		y += lineHeight
+	}
+
+	y += lineHeight
+	if s.changed {
+		text.Draw(screen, "Changes apply the next time the game starts", g.Options.SmallFont, x, y, color.White)
+		y += lineHeight
+	}
+	if s.status != "" {
+		text.Draw(screen, s.status, g.Options.SmallFont, x, y, red)
+		y += lineHeight
+	}
+	// Problems of the packs loaded at start
+	for _, err := range assets.Errors() {
+		if y > int(g.Options.ScreenHeight)-g.Options.ScreenYMenuHeight*2 {
+			break
+		}
+		text.Draw(screen, err.Error(), g.Options.SmallFont, x, y, red)
+		y += lineHeight
+	}
+	text.Draw(screen, "[enter] enable/disable  [w/s] move up/down  [esc] back", g.Options.SmallFont, x, int(g.Options.ScreenHeight)-g.Options.ScreenYMenuHeight, grey)
+}
@@ -0,0 +1,455 @@
+package game
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"astrogame/config"
+)
+
+// movement flies an enemy for one tick. Every enemy gets its own, made when
+// it spawns.
+type movement interface {
+	update(e *Enemy)
+}
+
+// movementKind builds the movements of the enemies of one batch laid out in
+// slots, so that they can share state like the path of a leader. Random
+// values come from the game's generator, a seed always flies the same way.
+type movementKind func(g *Game, slots []formationSlot) func(e *Enemy, i int) movement
+
+var movements = map[string]movementKind{
+	config.MovementStraight: each(newStraightMovement),
+	config.MovementSine:     each(newSineMovement),
+	config.MovementZigzag:   each(newZigzagMovement),
+	config.MovementCircle:   each(newCircleMovement),
+	config.MovementDive:     each(newDiveMovement),
+	config.MovementBezier:   each(newBezierMovement),
+	config.MovementHover:    each(newHoverMovement),
+	config.MovementFollow:   newFollowMovements,
+}
+
+// each is a movement kind whose enemies fly on their own once they are
+// through the entry of their slot.
+func each(fn func(g *Game, e *Enemy) movement) movementKind {
+	return func(g *Game, slots []formationSlot) func(e *Enemy, i int) movement {
+		return func(e *Enemy, i int) movement {
+			return enter(e, slots[i].entry, func() movement { return fn(g, e) })
+		}
+	}
+}
+
+// batchMovements returns what makes the movements of a batch laid out in
+// slots. Unknown names fly straight.
+func (g *Game) batchMovements(name string, slots []formationSlot) func(e *Enemy, i int) movement {
+	kind, ok := movements[name]
+	if !ok {
+		kind = movements[config.MovementStraight]
+	}
+	return kind(g, slots)
+}
+
+// entryMovement flies an entry path without firing. The movement after it is
+// made where the path ends, so that it starts from there.
+type entryMovement struct {
+	path []config.Vector
+	next func() movement
+	move movement
+}
+
+func enter(e *Enemy, path []config.Vector, next func() movement) movement {
+	if len(path) == 0 {
+		return next()
+	}
+	e.holdFire = true
+	return &entryMovement{path: path, next: next}
+}
+
+func (m *entryMovement) update(e *Enemy) {
+	if m.move != nil {
+		m.move.update(e)
+		return
+	}
+	if e.moveTo(m.path[0], e.enemyType.Velocity*2) {
+		m.path = m.path[1:]
+		if len(m.path) == 0 {
+			e.holdFire = false
+			m.move = m.next()
+		}
+	}
+}
+
+func (g *Game) randFloat(min, max float64) float64 {
+	return min + g.rng.Float64()*(max-min)
+}
+
+// randSign returns 1 or -1.
+func (g *Game) randSign() float64 {
+	if g.rng.Intn(2) == 0 {
+		return -1
+	}
+	return 1
+}
+
+// face turns the enemy the way it moved, zero faces down like the enemy
+// projectiles.
+func (e *Enemy) face(dx, dy float64) {
+	if dx != 0 || dy != 0 {
+		e.rotation = math.Atan2(-dx, dy)
+	}
+}
+
+// moveTo moves the enemy by at most speed towards target and reports whether
+// it got there.
+func (e *Enemy) moveTo(target config.Vector, speed float64) bool {
+	dx := target.X - e.position.X
+	dy := target.Y - e.position.Y
+	dist := math.Hypot(dx, dy)
+	if dist <= speed {
+		e.position = target
+		return true
+	}
+	e.position.X += dx / dist * speed
+	e.position.Y += dy / dist * speed
+	e.face(dx, dy)
+	return false
+}
+
+// straightMovement flies down or homes on the target set by the game, the
+// way enemies always did.
+type straightMovement struct{}
+
+// newStraightMovement aims straight enemies below where they are, as they
+// may have come in from elsewhere.
+func newStraightMovement(g *Game, e *Enemy) movement {
+	if e.TargetType != config.TargetTypePlayer {
+		e.target = config.Vector{X: e.position.X, Y: g.Options.ScreenHeight + 10}
+	}
+	e.SetDirection(e.target, e.position, *e.enemyType)
+	return straightMovement{}
+}
+
+func (straightMovement) update(e *Enemy) {
+	e.position.X += e.movement.X
+	e.position.Y += e.movement.Y
+	direction := config.Vector{
+		X: e.target.X - e.position.X,
+		Y: e.target.Y - e.position.Y,
+	}
+	normalizedDirection := direction.Normalize()
+
+	e.movement = config.Vector{
+		X: normalizedDirection.X * e.enemyType.Velocity,
+		Y: normalizedDirection.Y * e.enemyType.Velocity,
+	}
+	if e.TargetType == config.TargetTypePlayer {
+		e.rotation = math.Atan2(float64(e.target.Y-e.position.Y), float64(e.target.X-e.position.X))
+		e.rotation -= (90 * math.Pi) / 180
+	}
+}
+
+// waveMovement flies down while swinging from side to side around the X it
+// spawned at. shape maps the phase to an offset between -1 and 1.
+type waveMovement struct {
+	x         float64
+	amplitude float64
+	frequency float64
+	ticks     int
+	shape     func(phase float64) float64
+}
+
+func newWaveMovement(g *Game, e *Enemy, amplitude, period float64, shape func(float64) float64) *waveMovement {
+	return &waveMovement{
+		x:         e.position.X,
+		amplitude: g.randSign() * amplitude,
+		frequency: 2 * math.Pi / period,
+		shape:     shape,
+	}
+}
+
+func newSineMovement(g *Game, e *Enemy) movement {
+	amplitude := g.Options.ScreenWidth * g.randFloat(0.04, 0.1)
+	return newWaveMovement(g, e, amplitude, g.randFloat(120, 200), math.Sin)
+}
+
+// newZigzagMovement swings along straight legs, the triangle wave with the
+// phase of a sine.
+func newZigzagMovement(g *Game, e *Enemy) movement {
+	amplitude := g.Options.ScreenWidth * g.randFloat(0.05, 0.12)
+	return newWaveMovement(g, e, amplitude, g.randFloat(90, 150), func(phase float64) float64 {
+		return 2 / math.Pi * math.Asin(math.Sin(phase))
+	})
+}
+
+func (m *waveMovement) update(e *Enemy) {
+	m.ticks++
+	x := m.x + m.amplitude*m.shape(m.frequency*float64(m.ticks))
+	dx := x - e.position.X
+	e.position.X = x
+	e.position.Y += e.enemyType.Velocity
+	e.face(dx, e.enemyType.Velocity)
+}
+
+const (
+	movementEntering = iota
+	movementActing
+	movementReturning
+	movementLeaving
+)
+
+// circleMovement comes down to a height, circles around a center that drifts
+// over the player a few times, then leaves downwards.
+type circleMovement struct {
+	stage  int
+	holdY  float64
+	radius float64
+	angle  float64
+	spin   float64
+	left   float64
+	center config.Vector
+}
+
+func newCircleMovement(g *Game, e *Enemy) movement {
+	radius := g.Options.ScreenWidth * g.randFloat(0.06, 0.1)
+	return &circleMovement{
+		holdY:  g.Options.ScreenHeight * g.randFloat(0.15, 0.35),
+		radius: radius,
+		spin:   g.randSign() * e.enemyType.Velocity * 1.5 / radius,
+		left:   4 * math.Pi,
+	}
+}
+
+func (m *circleMovement) update(e *Enemy) {
+	velocity := e.enemyType.Velocity
+	switch m.stage {
+	case movementEntering:
+		e.position.Y += velocity
+		e.face(0, velocity)
+		if e.position.Y >= m.holdY {
+			// Start at the top of the circle
+			m.angle = -math.Pi / 2
+			m.center = config.Vector{X: e.position.X, Y: e.position.Y + m.radius}
+			m.stage = movementActing
+		}
+	case movementActing:
+		drift := e.game.player.position.X - m.center.X
+		m.center.X += max(min(drift, 0.5), -0.5)
+		m.angle += m.spin
+		m.left -= math.Abs(m.spin)
+		x := m.center.X + m.radius*math.Cos(m.angle)
+		y := m.center.Y + m.radius*math.Sin(m.angle)
+		e.face(x-e.position.X, y-e.position.Y)
+		e.position = config.Vector{X: x, Y: y}
+		if m.left <= 0 {
+			m.stage = movementLeaving
+		}
+	default:
+		e.position.Y += velocity
+		e.face(0, velocity)
+	}
+}
+
+// diveMovement comes down to a height, then dives at where the player was
+// and climbs back a few times before leaving downwards.
+type diveMovement struct {
+	stage  int
+	holdY  float64
+	wait   int
+	waited int
+	dives  int
+	target config.Vector
+	home   config.Vector
+}
+
+func newDiveMovement(g *Game, e *Enemy) movement {
+	return &diveMovement{
+		holdY: g.Options.ScreenHeight * g.randFloat(0.1, 0.3),
+		wait:  30 + g.rng.Intn(40),
+		dives: 2 + g.rng.Intn(2),
+	}
+}
+
+func (m *diveMovement) update(e *Enemy) {
+	velocity := e.enemyType.Velocity
+	switch m.stage {
+	case movementEntering:
+		if m.waited == 0 {
+			e.position.Y += velocity
+			e.face(0, velocity)
+			if e.position.Y < m.holdY {
+				return
+			}
+			m.home = e.position
+		}
+		// Aim before the dive
+		player := e.game.player.position
+		e.face(player.X-e.position.X, player.Y-e.position.Y)
+		m.waited++
+		if m.waited >= m.wait {
+			m.waited = 0
+			m.target = player
+			m.stage = movementActing
+		}
+	case movementActing:
+		if e.moveTo(m.target, velocity*2.5) {
+			m.dives--
+			m.stage = movementReturning
+			if m.dives <= 0 {
+				m.stage = movementLeaving
+			}
+		}
+	case movementReturning:
+		if e.moveTo(m.home, velocity*1.5) {
+			// Wait at the height again, facing the player
+			m.waited = 1
+			m.stage = movementEntering
+		}
+	default:
+		e.position.Y += velocity * 2
+		e.face(0, velocity)
+	}
+}
+
+// bezierMovement follows a cubic Bezier curve from the spawn point to below
+// the screen through two random control points, at the enemy's velocity.
+type bezierMovement struct {
+	points [4]config.Vector
+	t      float64
+}
+
+func newBezierMovement(g *Game, e *Enemy) movement {
+	w, h := g.Options.ScreenWidth, g.Options.ScreenHeight
+	return &bezierMovement{points: [4]config.Vector{
+		e.position,
+		{X: g.randFloat(0, w), Y: h * g.randFloat(0.2, 0.5)},
+		{X: g.randFloat(0, w), Y: h * g.randFloat(0.5, 0.9)},
+		{X: g.randFloat(0.1*w, 0.9*w), Y: h * 1.2},
+	}}
+}
+
+func (m *bezierMovement) update(e *Enemy) {
+	velocity := e.enemyType.Velocity
+	if m.t >= 1 {
+		e.position.Y += velocity
+		e.face(0, velocity)
+		return
+	}
+	p := m.points
+	u := 1 - m.t
+	// The derivative keeps the speed along the curve even
+	dx := 3*u*u*(p[1].X-p[0].X) + 6*u*m.t*(p[2].X-p[1].X) + 3*m.t*m.t*(p[3].X-p[2].X)
+	dy := 3*u*u*(p[1].Y-p[0].Y) + 6*u*m.t*(p[2].Y-p[1].Y) + 3*m.t*m.t*(p[3].Y-p[2].Y)
+	m.t = min(m.t+velocity/max(math.Hypot(dx, dy), 1), 1)
+	t := m.t
+	u = 1 - t
+	e.position = config.Vector{
+		X: u*u*u*p[0].X + 3*u*u*t*p[1].X + 3*u*t*t*p[2].X + t*t*t*p[3].X,
+		Y: u*u*u*p[0].Y + 3*u*u*t*p[1].Y + 3*u*t*t*p[2].Y + t*t*t*p[3].Y,
+	}
+	e.face(dx, dy)
+}
+
+// hoverMovement comes down to a height without firing, hovers there firing
+// for a while, then leaves downwards.
+type hoverMovement struct {
+	stage int
+	holdY float64
+	ticks int
+	hover int
+	x     float64
+}
+
+func newHoverMovement(g *Game, e *Enemy) movement {
+	e.holdFire = true
+	return &hoverMovement{
+		holdY: g.Options.ScreenHeight * g.randFloat(0.1, 0.4),
+		hover: ebiten.TPS() * (3 + g.rng.Intn(4)),
+	}
+}
+
+func (m *hoverMovement) update(e *Enemy) {
+	velocity := e.enemyType.Velocity
+	switch m.stage {
+	case movementEntering:
+		e.position.Y += velocity
+		e.face(0, velocity)
+		if e.position.Y >= m.holdY {
+			m.x = e.position.X
+			e.holdFire = false
+			m.stage = movementActing
+		}
+	case movementActing:
+		m.ticks++
+		e.position.X = m.x + 8*math.Sin(float64(m.ticks)/40)
+		e.position.Y = m.holdY + 4*math.Sin(float64(m.ticks)/25)
+		e.face(0, 1)
+		if m.ticks >= m.hover {
+			m.stage = movementLeaving
+		}
+	default:
+		e.position.Y += velocity
+	}
+}
+
+// leaderTrail is the path of the leader of a follow batch. It is flown by a
+// ghost that nobody can shoot, so the batch keeps its shape when the first
+// enemy dies.
+type leaderTrail struct {
+	ghost  *Enemy
+	path   movement
+	points []config.Vector
+	tick   int
+}
+
+// advance moves the ghost once per tick, whichever follower asks first.
+func (t *leaderTrail) advance(g *Game) {
+	if t.tick == g.runTicks {
+		return
+	}
+	t.tick = g.runTicks
+	t.path.update(t.ghost)
+	t.points = append(t.points, t.ghost.position)
+}
+
+// followMovement flies the leader's path a number of ticks behind it.
+type followMovement struct {
+	trail *leaderTrail
+	delay int
+}
+
+// newFollowMovements puts the whole batch on the first slot. The leader flies
+// its entry, then swings down the screen.
+func newFollowMovements(g *Game, slots []formationSlot) func(e *Enemy, i int) movement {
+	trail := &leaderTrail{tick: g.runTicks}
+	start := slots[0].position
+	return func(e *Enemy, i int) movement {
+		if trail.ghost == nil {
+			ghost := &Enemy{game: g, position: start, enemyType: e.enemyType}
+			width := float64(e.sprite().Bounds().Dx())
+			trail.ghost = ghost
+			trail.path = enter(ghost, slots[0].entry, func() movement {
+				amplitude := g.Options.ScreenWidth * g.randFloat(0.15, 0.3)
+				wave := newWaveMovement(g, ghost, amplitude, g.randFloat(240, 360), math.Sin)
+				// Keep the swing on the screen
+				wave.x = max(min(ghost.position.X, g.Options.ScreenWidth-amplitude-width), amplitude)
+				return wave
+			})
+		}
+		e.position = start
+		// Enough ticks for the followers not to overlap
+		gap := int(float64(e.sprite().Bounds().Dy())*1.3/e.enemyType.Velocity) + 1
+		return &followMovement{trail: trail, delay: i * gap}
+	}
+}
+
+func (m *followMovement) update(e *Enemy) {
+	m.trail.advance(e.game)
+	i := len(m.trail.points) - 1 - m.delay
+	if i < 0 {
+		return
+	}
+	p := m.trail.points[i]
+	e.face(p.X-e.position.X, p.Y-e.position.Y)
+	e.position = p
+}
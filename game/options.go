@@ -11,12 +11,12 @@ import (
 )
 
 type OptionsMenu struct {
+	sceneHooks
 	Game  *Game
 	Items []*MenuItem
 }
 
 func NewOptionsMenu(g *Game) *OptionsMenu {
-	scale := ebiten.DeviceScaleFactor()
 	OptionsMenu := OptionsMenu{
 		Game: g,
 		Items: []*MenuItem{
@@ -26,27 +26,9 @@ func NewOptionsMenu(g *Game) *OptionsMenu {
 				Choosen: true,
 				Pos:     0,
 				Action: func(g *Game) error {
-					g.Options.ScreenWidth = config.ScreenWidth1024X768 * scale
-					g.Options.ScreenHeight = config.ScreenHeight1024X768 * scale
-					g.Options.ScreenFontHeight = int(config.Screen1024X768FontHeight * scale)
-					g.Options.ScreenFontWidth = int(config.Screen1024X768FontWidth * scale)
-					g.Options.ScreenXProfileShift = int(config.Screen1024X768XProfileShift * scale)
-					g.Options.ScreenYProfileShift = int(config.Screen1024X768YProfileShift * scale)
-					g.Options.ScreenXMenuShift = int(config.Screen1024X768XMenuShift * scale)
-					g.Options.ScreenYMenuShift = int(config.Screen1024X768YMenuShift * scale)
-					g.Options.ScreenYMenuHeight = int(config.Screen1024X768YMenuHeight * scale)
-					g.Options.ScreenYProfileMenuShift = int(config.Screen1024X768YProfileMenuShift * scale)
-					g.Options.ScoreFont = assets.ScoreFont1024x768
-					g.Options.InfoFont = assets.InfoFont1024x768
-					g.Options.SmallFont = assets.SmallFont1024x768
-					g.Options.ProfileFont = assets.ProfileFont1024x768
-					g.Options.ProfileBigFont = assets.ProfileBigFont1024x768
-					g.Options.ResolutionMultipler = 0.5
-					g.Options.ProjectileResMulti = 1
-					g.Options.ResolutionMultiplerX = 1
-					g.Options.ResolutionMultiplerY = 1
-					g.ResolutionChange = true
+					g.setResolution(config.ScreenWidth1024X768)
 					ebiten.SetWindowSize(int(g.Options.ScreenWidth), int(g.Options.ScreenHeight))
+					g.saveSettings()
 					return nil
 				},
 			},
@@ -56,27 +38,9 @@ func NewOptionsMenu(g *Game) *OptionsMenu {
 				Choosen: false,
 				Pos:     1,
 				Action: func(g *Game) error {
-					g.Options.ScreenWidth = config.ScreenWidth1920x1080 * scale
-					g.Options.ScreenHeight = config.ScreenHeight1920x1080 * scale
-					g.Options.ScreenFontHeight = int(config.Screen1920x1080FontHeight * scale)               //config.Screen1920x1080FontHeight
-					g.Options.ScreenFontWidth = int(config.Screen1920x1080FontWidth * scale)                 //config.Screen1920x1080FontWidth
-					g.Options.ScreenXProfileShift = int(config.Screen1920x1080XProfileShift * scale)         //config.Screen1920x1080XProfileShift
-					g.Options.ScreenYProfileShift = int(config.Screen1920x1080YProfileShift * scale)         //config.Screen1920x1080YProfileShift
-					g.Options.ScreenXMenuShift = int(config.Screen1920x1080XMenuShift * scale)               //config.Screen1920x1080XMenuShift
-					g.Options.ScreenYMenuShift = int(config.Screen1920x1080YMenuShift * scale)               //config.Screen1920x1080YMenuShift
-					g.Options.ScreenYMenuHeight = int(config.Screen1920x1080YMenuHeight * scale)             //config.Screen1920x1080YMenuHeight
-					g.Options.ScreenYProfileMenuShift = int(config.Screen1920x1080YProfileMenuShift * scale) //config.Screen1920x1080YProfileMenuShift
-					g.Options.ScoreFont = assets.ScoreFont1920x1080
-					g.Options.InfoFont = assets.InfoFont1920x1080
-					g.Options.SmallFont = assets.SmallFont1920x1080
-					g.Options.ProfileFont = assets.ProfileFont1920x1080
-					g.Options.ProfileBigFont = assets.ProfileBigFont1920x1080
-					g.Options.ResolutionMultipler = 1
-					g.Options.ProjectileResMulti = 2
-					g.Options.ResolutionMultiplerX = 1.875
-					g.Options.ResolutionMultiplerY = 1.40625
-					g.ResolutionChange = true
+					g.setResolution(config.ScreenWidth1920x1080)
 					ebiten.SetWindowSize(int(g.Options.ScreenWidth), int(g.Options.ScreenHeight))
+					g.saveSettings()
 					return nil
 				},
 			},
@@ -89,6 +53,7 @@ func NewOptionsMenu(g *Game) *OptionsMenu {
 					if g.Options.Fullscreen {
 						g.Options.Fullscreen = false
 						ebiten.SetFullscreen(false)
+						g.saveSettings()
 						return nil
 					}
 					g.Options.Fullscreen = true
@@ -98,16 +63,29 @@ func NewOptionsMenu(g *Game) *OptionsMenu {
 						xOffsetMod := (float64(fw) - float64(g.Options.ScreenWidth)) / 2
 						ebiten.SetWindowPosition(int(xOffsetMod), 100)
 					}
+					g.saveSettings()
 					return nil
 				},
 			},
 			{
-				Label:   "main menu",
+				Label:   "meta progression on/off",
 				Active:  true,
 				Choosen: false,
 				Pos:     4,
 				Action: func(g *Game) error {
-					g.state = config.MainMenu
+					g.settings.MetaProgression = !g.settings.MetaProgression
+					g.saveSettings()
+					g.menu.updateItems()
+					return nil
+				},
+			},
+			{
+				Label:   "main menu",
+				Active:  true,
+				Choosen: false,
+				Pos:     5,
+				Action: func(g *Game) error {
+					g.scenes.Pop()
 					return nil
 				},
 			},
@@ -131,3 +109,73 @@ func (m *OptionsMenu) Update() error {
 func (m *OptionsMenu) Draw(screen *ebiten.Image) {
 	MenuDraw(m.Game, m.Items, screen)
 }
+
+// setResolution switches every screen-size dependent option to one of the
+// supported resolutions, identified by its width.
+func (g *Game) setResolution(width int) {
+	scale := g.Options.DeviceScale
+	switch width {
+	case config.ScreenWidth1920x1080:
+		g.Options.ScreenWidth = config.ScreenWidth1920x1080 * scale
+		g.Options.ScreenHeight = config.ScreenHeight1920x1080 * scale
+		g.Options.ScreenFontHeight = int(config.Screen1920x1080FontHeight * scale)
+		g.Options.ScreenFontWidth = int(config.Screen1920x1080FontWidth * scale)
+		g.Options.ScreenXProfileShift = int(config.Screen1920x1080XProfileShift * scale)
+		g.Options.ScreenYProfileShift = int(config.Screen1920x1080YProfileShift * scale)
+		g.Options.ScreenXMenuShift = int(config.Screen1920x1080XMenuShift * scale)
+		g.Options.ScreenYMenuShift = int(config.Screen1920x1080YMenuShift * scale)
+		g.Options.ScreenYMenuHeight = int(config.Screen1920x1080YMenuHeight * scale)
+		g.Options.ScreenYProfileMenuShift = int(config.Screen1920x1080YProfileMenuShift * scale)
+		g.Options.ScoreFont = assets.ScoreFont1920x1080
+		g.Options.InfoFont = assets.InfoFont1920x1080
+		g.Options.SmallFont = assets.SmallFont1920x1080
+		g.Options.ProfileFont = assets.ProfileFont1920x1080
+		g.Options.ProfileBigFont = assets.ProfileBigFont1920x1080
+		g.Options.ResolutionMultipler = 1
+		g.Options.ProjectileResMulti = 2
+		g.Options.ResolutionMultiplerX = 1.875
+		g.Options.ResolutionMultiplerY = 1.40625
+	default:
+		g.Options.ScreenWidth = config.ScreenWidth1024X768 * scale
+		g.Options.ScreenHeight = config.ScreenHeight1024X768 * scale
+		g.Options.ScreenFontHeight = int(config.Screen1024X768FontHeight * scale)
+		g.Options.ScreenFontWidth = int(config.Screen1024X768FontWidth * scale)
+		g.Options.ScreenXProfileShift = int(config.Screen1024X768XProfileShift * scale)
+		g.Options.ScreenYProfileShift = int(config.Screen1024X768YProfileShift * scale)
+		g.Options.ScreenXMenuShift = int(config.Screen1024X768XMenuShift * scale)
+		g.Options.ScreenYMenuShift = int(config.Screen1024X768YMenuShift * scale)
+		g.Options.ScreenYMenuHeight = int(config.Screen1024X768YMenuHeight * scale)
+		g.Options.ScreenYProfileMenuShift = int(config.Screen1024X768YProfileMenuShift * scale)
+		g.Options.ScoreFont = assets.ScoreFont1024x768
+		g.Options.InfoFont = assets.InfoFont1024x768
+		g.Options.SmallFont = assets.SmallFont1024x768
+		g.Options.ProfileFont = assets.ProfileFont1024x768
+		g.Options.ProfileBigFont = assets.ProfileBigFont1024x768
+		g.Options.ResolutionMultipler = 0.5
+		g.Options.ProjectileResMulti = 1
+		g.Options.ResolutionMultiplerX = 1
+		g.Options.ResolutionMultiplerY = 1
+	}
+	g.Options.setSpriteScales()
+	g.sprites.Clear()
+	g.Options.ResolutionWidth = width
+	g.ResolutionChange = true
+}
+
+// Enemies, items and icons are drawn at the same scale at every resolution.
+const (
+	enemySpriteScale = 0.5
+	itemSpriteScale  = 0.5
+	iconSpriteScale  = 0.5
+	// projectileShrink draws projectiles a little smaller than
+	// ProjectileResMulti
+	projectileShrink = 0.2
+)
+
+// setSpriteScales derives the sprite scales from the resolution multipliers.
+func (o *options) setSpriteScales() {
+	o.EnemyScale = enemySpriteScale
+	o.ItemScale = itemSpriteScale
+	o.IconScale = iconSpriteScale
+	o.ProjectileScale = o.ProjectileResMulti - projectileShrink
+}
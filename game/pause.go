@@ -0,0 +1,160 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"astrogame/config"
+)
+
+// PauseMenu is drawn over the frozen game. Nothing in the run advances while
+// it is open.
+type PauseMenu struct {
+	sceneHooks
+	Game  *Game
+	Items []*MenuItem
+}
+
+func NewPauseMenu(g *Game) *PauseMenu {
+	PauseMenu := PauseMenu{
+		Game: g,
+		Items: []*MenuItem{
+			{
+				Label:   "Resume",
+				Active:  true,
+				Choosen: true,
+				Pos:     0,
+				Action: func(g *Game) error {
+					g.scenes.Pop()
+					return nil
+				},
+			},
+			{
+				Label:   "Restart stage",
+				Active:  true,
+				Choosen: false,
+				Pos:     1,
+				Action: func(g *Game) error {
+					g.RestartStage()
+					g.scenes.Pop()
+					return nil
+				},
+			},
+			{
+				Label:   "Options",
+				Active:  true,
+				Choosen: false,
+				Pos:     2,
+				Action: func(g *Game) error {
+					g.scenes.Push(g.optionsMenu)
+					return nil
+				},
+			},
+			{
+				Label:   "Quit to menu",
+				Active:  true,
+				Choosen: false,
+				Pos:     3,
+				Action: func(g *Game) error {
+					if g.playtest != nil {
+						g.Reset()
+						return nil
+					}
+					g.saveRun()
+					g.scenes.Switch(g.menu)
+					return nil
+				},
+			},
+		},
+	}
+	sort.Slice(PauseMenu.Items, func(i, j int) bool { return PauseMenu.Items[i].Pos < PauseMenu.Items[j].Pos })
+	for idx, i := range PauseMenu.Items {
+		chars := len([]rune(i.Label))
+		i.vector = image.Rectangle{
+			Min: image.Point{X: int(g.Options.ScreenWidth/2) - g.Options.ScreenXMenuShift, Y: int(g.Options.ScreenHeight/2) - g.Options.ScreenYMenuShift + g.Options.ScreenYMenuHeight*idx - g.Options.ScreenFontHeight},
+			Max: image.Point{X: int(g.Options.ScreenWidth/2) - g.Options.ScreenXMenuShift + chars*g.Options.ScreenFontWidth, Y: int(g.Options.ScreenHeight/2) - g.Options.ScreenYMenuShift + g.Options.ScreenYMenuHeight*idx + g.Options.ScreenFontHeight},
+		}
+	}
+	return &PauseMenu
+}
+
+func (m *PauseMenu) Overlay() bool {
+	return true
+}
+
+func (m *PauseMenu) Update() error {
+	return MenuUpdate(m.Game, m.Items)
+}
+
+func (m *PauseMenu) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, float32(m.Game.Options.ScreenWidth), float32(m.Game.Options.ScreenHeight), color.RGBA{0, 0, 0, 160}, false)
+	MenuDraw(m.Game, m.Items, screen)
+}
+
+// stageCheckpoint is the state of the run when the current stage began.
+type stageCheckpoint struct {
+	stage            config.Stage
+	params           PlayerParams
+	speed            float64
+	shield           int
+	weapons          []savedWeapon
+	curWeapon        int
+	secondaryWeapons []savedWeapon
+	curSecondary     int
+	score            int
+	credits          int
+	ticks            int
+}
+
+func copyStage(s config.Stage) config.Stage {
+	c := s
+	c.Items = slices.Clone(s.Items)
+	c.Waves = slices.Clone(s.Waves)
+	for i := range c.Waves {
+		c.Waves[i].Batches = slices.Clone(s.Waves[i].Batches)
+	}
+	return c
+}
+
+// beginStage remembers the current stage before anything of it is spawned.
+func (g *Game) beginStage() {
+	g.boss = nil
+	g.bossDefeated = false
+	p := g.player
+	g.checkpoint = stageCheckpoint{
+		stage:   copyStage(*g.CurStage),
+		params:  *p.params,
+		speed:   p.params.speed,
+		score:   g.score,
+		credits: g.profile.credits,
+		ticks:   g.runTicks,
+	}
+	g.checkpoint.params.Upgrades = maps.Clone(p.params.Upgrades)
+	if p.shield != nil {
+		g.checkpoint.shield = p.shield.HP
+	}
+	g.checkpoint.weapons, g.checkpoint.curWeapon = saveWeapons(p.weapons, p.curWeapon)
+	g.checkpoint.secondaryWeapons, g.checkpoint.curSecondary = saveWeapons(p.secondaryWeapons, p.curSecondaryWeapon)
+	g.saveRun()
+}
+
+// RestartStage throws away everything of the current stage and plays it again
+// from its first wave.
+func (g *Game) RestartStage() {
+	g.world.Clear()
+	g.boss = nil
+	g.bossDefeated = false
+	g.player.respawnAnimations()
+	*g.CurStage = copyStage(g.checkpoint.stage)
+	g.CurWave = &g.CurStage.Waves[0]
+	g.player.params.HP = g.checkpoint.params.HP
+	g.score = g.checkpoint.score
+	g.resetRunTimers()
+	g.publishWaveStarted()
+}
@@ -0,0 +1,374 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	pilotsFileName = "pilots.json"
+	pilotsVersion  = 1
+	maxPilots      = 8
+)
+
+type PilotStats struct {
+	Runs          int           `json:"runs"`
+	Kills         int           `json:"kills"`
+	BestScore     int           `json:"bestScore"`
+	LevelsCleared int           `json:"levelsCleared"`
+	PlayTime      time.Duration `json:"playTime"`
+}
+
+// Pilot is one player sharing the machine. Its run save and settings live in
+// a folder of its own.
+type Pilot struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Created  time.Time    `json:"created"`
+	Stats    PilotStats   `json:"stats"`
+	Unlocked []string     `json:"unlocked"`
+	Meta     MetaProgress `json:"meta"`
+}
+
+// dataPath returns the path of a per-pilot file. Without a pilot the file
+// lives directly in the game folder, where it was before there were pilots.
+func (p *Pilot) dataPath(name string) (string, error) {
+	if p == nil {
+		return dataPath(name)
+	}
+	return dataPath("pilots", p.ID, name)
+}
+
+func (p *Pilot) HasShip(name string) bool {
+	return slices.Contains(p.Unlocked, name)
+}
+
+// unlockShips gives the pilot every ship its cleared levels have earned.
+func (p *Pilot) unlockShips() {
+	for _, s := range Ships() {
+		if s.UnlockLevel <= p.Stats.LevelsCleared && !p.HasShip(s.Name) {
+			p.Unlocked = append(p.Unlocked, s.Name)
+		}
+	}
+}
+
+type PilotRoster struct {
+	Version int      `json:"version"`
+	Current string   `json:"current"`
+	Pilots  []*Pilot `json:"pilots"`
+}
+
+// LoadPilots reads the pilots of this machine. A missing or broken file
+// gives an empty roster.
+func LoadPilots() *PilotRoster {
+	r := &PilotRoster{Version: pilotsVersion}
+	path, err := dataPath(pilotsFileName)
+	if err != nil {
+		log.Println(err)
+		return r
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r
+	}
+	if err == nil {
+		err = json.Unmarshal(data, r)
+	}
+	if err == nil && r.Version > pilotsVersion {
+		err = fmt.Errorf("unsupported version %d", r.Version)
+	}
+	if err != nil {
+		log.Printf("pilots: %v", err)
+		return &PilotRoster{Version: pilotsVersion}
+	}
+	r.Version = pilotsVersion
+	r.Pilots = slices.DeleteFunc(r.Pilots, func(p *Pilot) bool {
+		return p == nil || p.ID == "" || filepath.Base(p.ID) != p.ID
+	})
+	for _, p := range r.Pilots {
+		p.unlockShips()
+	}
+	return r
+}
+
+func (r *PilotRoster) Save() error {
+	path, err := dataPath(pilotsFileName)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (r *PilotRoster) CurrentPilot() *Pilot {
+	for _, p := range r.Pilots {
+		if p.ID == r.Current {
+			return p
+		}
+	}
+	return nil
+}
+
+// Add creates a new pilot. The first pilot takes over the save and settings
+// that were written before there were pilots.
+func (r *PilotRoster) Add(name string) *Pilot {
+	p := &Pilot{
+		ID:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		Name:    name,
+		Created: time.Now(),
+	}
+	p.unlockShips()
+	if len(r.Pilots) == 0 {
+		for _, f := range []string{saveFileName, settingsFileName} {
+			from, err := dataPath(f)
+			if err != nil {
+				continue
+			}
+			to, err := p.dataPath(f)
+			if err != nil {
+				continue
+			}
+			err = os.Rename(from, to)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Println(err)
+			}
+		}
+	}
+	r.Pilots = append(r.Pilots, p)
+	return p
+}
+
+// Remove deletes the pilot together with its files.
+func (r *PilotRoster) Remove(p *Pilot) {
+	r.Pilots = slices.DeleteFunc(r.Pilots, func(o *Pilot) bool { return o == p })
+	if r.Current == p.ID {
+		r.Current = ""
+	}
+	dir, err := dataPath("pilots", p.ID)
+	if err == nil {
+		err = os.RemoveAll(dir)
+	}
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (g *Game) savePilots() {
+	if !g.persist {
+		return
+	}
+	err := g.pilots.Save()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// shipUnlocked tells whether the current pilot may fly the ship. Without
+// pilots every ship is available.
+func (g *Game) shipUnlocked(s *ShipDef) bool {
+	return g.pilot == nil || g.pilot.HasShip(s.Name)
+}
+
+// selectPilot hands the game to another pilot. The run of the previous pilot
+// is saved for later and the settings of the new one are applied.
+func (g *Game) selectPilot(p *Pilot) {
+	if g.pilot != p {
+		g.saveRun()
+		g.clearRun()
+		g.pilot = p
+		g.applySettings(LoadSettings(p))
+	}
+	g.pilots.Current = p.ID
+	g.savePilots()
+	g.menu.updateItems()
+}
+
+// subscribePilotStats keeps the statistics of the current pilot.
+func (g *Game) subscribePilotStats() {
+	Subscribe(g.events, func(e EnemyKilled) {
+		if g.pilot != nil {
+			g.pilot.Stats.Kills++
+		}
+	})
+	Subscribe(g.events, func(e LevelCleared) {
+		if g.pilot != nil && e.Level+1 > g.pilot.Stats.LevelsCleared {
+			g.pilot.Stats.LevelsCleared = e.Level + 1
+			g.pilot.unlockShips()
+			g.savePilots()
+		}
+	})
+}
+
+func (g *Game) recordPilotRun() {
+	if g.pilot == nil {
+		return
+	}
+	g.pilot.Stats.Runs++
+	g.pilot.Stats.BestScore = max(g.pilot.Stats.BestScore, g.score)
+	g.pilot.Stats.PlayTime += g.runDuration()
+	g.savePilots()
+}
+
+type pilotsMode int
+
+const (
+	pilotsList pilotsMode = iota
+	pilotsNaming
+	pilotsDeleting
+)
+
+// pilotsScreen picks, creates, renames and deletes pilots.
+type pilotsScreen struct {
+	sceneHooks
+	game     *Game
+	sel      int
+	mode     pilotsMode
+	renaming *Pilot
+	name     textField
+}
+
+func (s *pilotsScreen) Enter() {
+	s.mode = pilotsList
+	s.sel = max(slices.Index(s.game.pilots.Pilots, s.game.pilot), 0)
+}
+
+func (s *pilotsScreen) Update() error {
+	g := s.game
+	switch s.mode {
+	case pilotsNaming:
+		s.name.Update(&g.input)
+		if g.input.IsKeyJustPressed(ebiten.KeyEnter) && len(s.name.text) > 0 {
+			if s.renaming != nil {
+				s.renaming.Name = s.name.String()
+				g.savePilots()
+			} else {
+				s.sel = len(g.pilots.Pilots)
+				g.selectPilot(g.pilots.Add(s.name.String()))
+			}
+			s.mode = pilotsList
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyEscape) {
+			s.mode = pilotsList
+		}
+		return nil
+	case pilotsDeleting:
+		if g.input.IsKeyJustPressed(ebiten.KeyY) {
+			p := g.pilots.Pilots[s.sel]
+			g.pilots.Remove(p)
+			if g.pilot == p {
+				g.clearRun()
+				g.pilot = nil
+			}
+			g.savePilots()
+			s.sel = max(min(s.sel, len(g.pilots.Pilots)-1), 0)
+			s.mode = pilotsList
+		}
+		if g.input.IsKeyJustPressed(ebiten.KeyN) || g.input.IsKeyJustPressed(ebiten.KeyEscape) {
+			s.mode = pilotsList
+		}
+		return nil
+	}
+
+	// The last row creates a new pilot
+	rows := len(g.pilots.Pilots) + 1
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		s.sel = (s.sel + 1) % rows
+	}
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		s.sel = (s.sel + rows - 1) % rows
+	}
+	onPilot := s.sel < len(g.pilots.Pilots)
+	if g.input.IsKeyJustPressed(ebiten.KeyEnter) {
+		if onPilot {
+			g.selectPilot(g.pilots.Pilots[s.sel])
+			g.scenes.Switch(g.menu)
+		} else if len(g.pilots.Pilots) < maxPilots {
+			s.renaming = nil
+			s.name = textField{max: maxNameLength}
+			s.mode = pilotsNaming
+		}
+	}
+	if onPilot && g.input.IsKeyJustPressed(ebiten.KeyR) {
+		s.renaming = g.pilots.Pilots[s.sel]
+		s.name = textField{max: maxNameLength}
+		s.name.Set(s.renaming.Name)
+		s.mode = pilotsNaming
+	}
+	if onPilot && g.input.IsKeyJustPressed(ebiten.KeyDelete) {
+		s.mode = pilotsDeleting
+	}
+	// Nobody may play without a pilot
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) && g.pilot != nil {
+		g.scenes.Switch(g.menu)
+	}
+	return nil
+}
+
+func (s *pilotsScreen) Draw(screen *ebiten.Image) {
+	g := s.game
+	g.DrawBg(screen)
+	x := int(g.Options.ScreenWidth/2) - g.Options.ScreenXMenuShift*2
+	y := g.Options.ScreenYMenuHeight * 2
+	lineHeight := g.Options.ScreenFontHeight * 3 / 2
+	text.Draw(screen, "Pilots", g.Options.ScoreFont, x, y, color.White)
+	y += g.Options.ScreenYMenuHeight
+
+	for i, p := range g.pilots.Pilots {
+		c := color.RGBA{255, 255, 255, 255}
+		if i == s.sel {
+			c = color.RGBA{179, 14, 14, 255}
+		}
+		name := p.Name
+		if s.mode == pilotsNaming && s.renaming == p {
+			name = s.name.String() + "_"
+		}
+		if p == g.pilot {
+			name = "* " + name
+		}
+		text.Draw(screen, name, g.Options.InfoFont, x, y, c)
+		stats := fmt.Sprintf("runs %d  kills %d  best %d  levels %d  time %s", p.Stats.Runs, p.Stats.Kills, p.Stats.BestScore, p.Stats.LevelsCleared, p.Stats.PlayTime.Round(time.Second))
+		text.Draw(screen, stats, g.Options.SmallFont, x, y+lineHeight*2/3, color.RGBA{100, 100, 100, 255})
+		y += lineHeight * 2
+	}
+	c := color.RGBA{255, 255, 255, 255}
+	if s.sel == len(g.pilots.Pilots) {
+		c = color.RGBA{179, 14, 14, 255}
+	}
+	if len(g.pilots.Pilots) >= maxPilots {
+		c = color.RGBA{100, 100, 100, 255}
+	}
+	label := "New pilot"
+	if s.mode == pilotsNaming && s.renaming == nil {
+		label = "Name: " + s.name.String() + "_"
+	}
+	text.Draw(screen, label, g.Options.InfoFont, x, y, c)
+
+	help := "[enter] select  [R] rename  [del] delete  [esc] back"
+	switch s.mode {
+	case pilotsNaming:
+		help = "[enter] confirm  [esc] cancel"
+	case pilotsDeleting:
+		help = fmt.Sprintf("Delete %s and all of their progress? [Y/N]", g.pilots.Pilots[s.sel].Name)
+	}
+	text.Draw(screen, help, g.Options.SmallFont, x, int(g.Options.ScreenHeight)-g.Options.ScreenYMenuHeight, color.RGBA{100, 100, 100, 255})
+}
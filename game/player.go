@@ -3,10 +3,8 @@ package game
 import (
 	"image"
 	"math"
-	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 
 	"astrogame/assets"
 	"astrogame/config"
@@ -17,36 +15,22 @@ const (
 	rotationPerSecond = math.Pi
 	maxAngle          = 256
 	bulletSpawnOffset = 50.0
+	// baseSecondarySlots holds every secondary weapon of the registry, so a
+	// run without hangar upgrades carries them all like it always did
+	baseSecondarySlots = 3
 )
 
 type PlayerParams struct {
-	Ship  *Ship
+	Ship  *Ship `json:"-"`
 	Level int
 	HP    int
 	speed float64
+	// SecondarySlots is the number of secondary weapons the ship can carry
+	SecondarySlots int
 
-	LightRocketSpeedUpscale       time.Duration
-	AutoLightRocketSpeedUpscale   time.Duration
-	DoubleLightRocketSpeedUpscale time.Duration
-	LaserCanonSpeedUpscale        time.Duration
-	DoubleLaserCanonSpeedUpscale  time.Duration
-	ClusterMinesSpeedUpscale      time.Duration
-	BigBombSpeedUpscale           time.Duration
-	MachineGunSpeedUpscale        time.Duration
-	DoubleMachineGunSpeedUpscale  time.Duration
-	PlasmaGunSpeedUpscale         time.Duration
-	DoublePlasmaGunSpeedUpscale   time.Duration
-	PentaLaserSpeedUpscale        time.Duration
-
-	LightRocketVelocityMultiplier       float64
-	AutoLightRocketVelocityMultiplier   float64
-	DoubleLightRocketVelocityMultiplier float64
-	ClusterMinesVelocityMultiplier      float64
-	BigBombVelocityMultiplier           float64
-	MachineGunVelocityMultiplier        float64
-	DoubleMachineGunVelocityMultiplier  float64
-	PlasmaGunVelocityMultiplier         float64
-	DoublePlasmaGunVelocityMultiplier   float64
+	// Upgrades holds the level of every weapon upgrade bought in the profile
+	// screen, by upgrade id
+	Upgrades map[string]int
 }
 
 func (p *PlayerParams) GetHealthPoints() int {
@@ -63,220 +47,15 @@ func (p *PlayerParams) IncreaseSpeed(i int) {
 	p.speed += float64(i)
 }
 
-func (p *PlayerParams) GetLightRocketSpeedUpscale() int {
-	return int(p.LightRocketSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseLightRocketSpeedUpscale(t int) {
-	if t > 0 {
-		p.LightRocketSpeedUpscale++
-	} else {
-		p.LightRocketSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetLightRocketVelocityMultiplier() int {
-	return int(p.LightRocketVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseLightRocketVelocityMultiplier(t int) {
-	p.LightRocketVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetAutoLightRocketSpeedUpscale() int {
-	return int(p.AutoLightRocketSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseAutoLightRocketSpeedUpscale(t int) {
-	if t > 0 {
-		p.AutoLightRocketSpeedUpscale++
-	} else {
-		p.AutoLightRocketSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetAutoLightRocketVelocityMultiplier() int {
-	return int(p.AutoLightRocketVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseAutoLightRocketVelocityMultiplier(t int) {
-	p.AutoLightRocketVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetDoubleLightRocketSpeedUpscale() int {
-	return int(p.DoubleLightRocketSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseDoubleLightRocketSpeedUpscale(t int) {
-	if t > 0 {
-		p.DoubleLightRocketSpeedUpscale++
-	} else {
-		p.DoubleLightRocketSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetDoubleLightRocketVelocityMultiplier() int {
-	return int(p.DoubleLightRocketVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseDoubleLightRocketVelocityMultiplier(t int) {
-	p.DoubleLightRocketVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetClusterMinesSpeedUpscale() int {
-	return int(p.ClusterMinesSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseClusterMinesSpeedUpscale(t int) {
-	if t > 0 {
-		p.ClusterMinesSpeedUpscale++
-	} else {
-		p.ClusterMinesSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetClusterMinesVelocityMultiplier() int {
-	return int(p.ClusterMinesVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseClusterMinesVelocityMultiplier(t int) {
-	p.ClusterMinesVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetPentaLaserSpeedUpscale() int {
-	return int(p.PentaLaserSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreasePentaLaserSpeedUpscale(t int) {
-	if t > 0 {
-		p.PentaLaserSpeedUpscale++
-	} else {
-		p.PentaLaserSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetLaserCanonSpeedUpscale() int {
-	return int(p.LaserCanonSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseLaserCanonSpeedUpscale(t int) {
-	if t > 0 {
-		p.LaserCanonSpeedUpscale++
-	} else {
-		p.LaserCanonSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetDoubleLaserCanonSpeedUpscale() int {
-	return int(p.DoubleLaserCanonSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseDoubleLaserCanonSpeedUpscale(t int) {
-	if t > 0 {
-		p.DoubleLaserCanonSpeedUpscale++
-	} else {
-		p.DoubleLaserCanonSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetBigBombSpeedUpscale() int {
-	return int(p.BigBombSpeedUpscale)
+func (p *PlayerParams) UpgradeLevel(id string) int {
+	return p.Upgrades[id]
 }
 
-func (p *PlayerParams) IncreaseBigBombSpeedUpscale(t int) {
-	if t > 0 {
-		p.BigBombSpeedUpscale++
-	} else {
-		p.BigBombSpeedUpscale--
+func (p *PlayerParams) IncreaseUpgrade(id string, t int) {
+	if p.Upgrades == nil {
+		p.Upgrades = map[string]int{}
 	}
-}
-
-func (p *PlayerParams) GetBigBombVelocityMultiplier() int {
-	return int(p.BigBombVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseBigBombVelocityMultiplier(t int) {
-	p.BigBombVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetMachineGunSpeedUpscale() int {
-	return int(p.MachineGunSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseMachineGunSpeedUpscale(t int) {
-	if t > 0 {
-		p.MachineGunSpeedUpscale++
-	} else {
-		p.MachineGunSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetMachineGunVelocityMultiplier() int {
-	return int(p.MachineGunVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseMachineGunVelocityMultiplier(t int) {
-	p.MachineGunVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetDoubleMachineGunSpeedUpscale() int {
-	return int(p.DoubleMachineGunSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseDoubleMachineGunSpeedUpscale(t int) {
-	if t > 0 {
-		p.DoubleMachineGunSpeedUpscale++
-	} else {
-		p.DoubleMachineGunSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetDoubleMachineGunVelocityMultiplier() int {
-	return int(p.DoubleMachineGunVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseDoubleMachineGunVelocityMultiplier(t int) {
-	p.DoubleMachineGunVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetPlasmaGunVelocityMultiplier() int {
-	return int(p.PlasmaGunVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreasePlasmaGunVelocityMultiplier(t int) {
-	p.PlasmaGunVelocityMultiplier += float64(t)
-}
-
-func (p *PlayerParams) GetPlasmaGunSpeedUpscale() int {
-	return int(p.PlasmaGunSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreasePlasmaGunSpeedUpscale(t int) {
-	if t > 0 {
-		p.PlasmaGunSpeedUpscale++
-	} else {
-		p.PlasmaGunSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetDoublePlasmaGunSpeedUpscale() int {
-	return int(p.DoublePlasmaGunSpeedUpscale)
-}
-
-func (p *PlayerParams) IncreaseDoublePlasmaGunSpeedUpscale(t int) {
-	if t > 0 {
-		p.DoublePlasmaGunSpeedUpscale++
-	} else {
-		p.DoublePlasmaGunSpeedUpscale--
-	}
-}
-
-func (p *PlayerParams) GetDoublePlasmaGunVelocityMultiplier() int {
-	return int(p.DoublePlasmaGunVelocityMultiplier)
-}
-
-func (p *PlayerParams) IncreaseDoublePlasmaGunVelocityMultiplier(t int) {
-	p.DoublePlasmaGunVelocityMultiplier += float64(t)
+	p.Upgrades[id] += t
 }
 
 type Player struct {
@@ -291,17 +70,28 @@ type Player struct {
 	secondaryWeapons    []*Weapon
 	curSecondaryWeapon  *Weapon
 	animations          []*Animation
+	baseSprite          *ebiten.Image
 	shield              *Shield
 }
 
 func (p *Player) SetShip(s *Ship) {
 	p.params.HP += s.HP
 	p.params.speed += s.Velocity
-	p.sprite = objects.ScaleImg(s.Sprite, p.game.Options.ResolutionMultipler)
-	p.weapons = append(p.weapons, s.UniqueWeapon)
-	p.curWeapon = s.UniqueWeapon
-	p.curWeapon.ammo = s.UniqueWeapon.projectile.wType.StartAmmo
+	p.baseSprite = s.Sprite.img
 	p.params.Ship = s
+	p.scaleSprite()
+	p.curWeapon = NewWeapon(s.UniqueWeapon, p)
+	p.weapons = append(p.weapons, p.curWeapon)
+}
+
+// scaleSprite scales the ship sprite for the current resolution, sprites of
+// ships marked fixed keep their size.
+func (p *Player) scaleSprite() {
+	scale := p.game.Options.ResolutionMultipler
+	if ref := p.params.Ship.Sprite; ref.img != nil {
+		scale = ref.scale(p.game, scale)
+	}
+	p.sprite = p.game.sprites.Scaled(p.baseSprite, scale)
 }
 
 type Shield struct {
@@ -310,8 +100,26 @@ type Shield struct {
 	HP       int
 }
 
+func (p *Player) addShield(hp int, sprite *ebiten.Image) {
+	w := p.sprite.Bounds().Dx()
+	h := p.sprite.Bounds().Dy()
+	px, py := p.position.X-float64(w)/2, p.position.Y-float64(h)/2
+	p.shield = &Shield{
+		position: p.position,
+		HP:       hp,
+		sprite:   sprite,
+	}
+	animationPos := config.Vector{
+		X: px,
+		Y: py,
+	}
+	shieldAnimation := NewAnimation(animationPos, "shield", 0)
+	p.animations = append(p.animations, shieldAnimation)
+	p.game.AddAnimation(shieldAnimation)
+}
+
 func NewPlayer(curgame *Game) *Player {
-	sprite := objects.ScaleImg(assets.PlayerSprite, curgame.Options.ResolutionMultipler)
+	sprite := curgame.sprites.Scaled(assets.PlayerSprite, curgame.Options.ResolutionMultipler)
 	bounds := sprite.Bounds()
 	halfW := float64(bounds.Dx()) / 2
 	halfH := float64(bounds.Dy()) / 2
@@ -325,34 +133,28 @@ func NewPlayer(curgame *Game) *Player {
 		Y: curgame.Options.ScreenHeight/2 + float64(bounds.Dy()/2),
 	}
 
-	engineFireburst := NewAnimation(posFireburst, assets.PlayerFireburstSpriteSheet, 1, 90, 85, true, "engineFireburst", 0)
+	engineFireburst := NewAnimation(posFireburst, "engineFireburst", 0)
 	curgame.AddAnimation(engineFireburst)
 	p := &Player{
 		params: &PlayerParams{
 			Level: 1,
 			HP:    10,
 			speed: 10,
-			Ship: &Ship{
+			Ship: &Ship{ShipDef{
 				HP:                0,
 				Velocity:          0,
 				HPMod:             0,
 				VelocityMod:       1,
 				WeaponFireRateMod: 1,
 				WeaponDamageMod:   1,
-			},
-			LightRocketVelocityMultiplier:       1,
-			AutoLightRocketVelocityMultiplier:   1,
-			DoubleLightRocketVelocityMultiplier: 1,
-			ClusterMinesVelocityMultiplier:      1,
-			BigBombVelocityMultiplier:           1,
-			MachineGunVelocityMultiplier:        1,
-			PlasmaGunVelocityMultiplier:         1,
-			DoublePlasmaGunVelocityMultiplier:   1,
+			}},
+			SecondarySlots: baseSecondarySlots,
 		},
 		game:                curgame,
 		position:            pos,
 		rotation:            0,
 		sprite:              sprite,
+		baseSprite:          assets.PlayerSprite,
 		objectRotationSpeed: 1.2,
 		animations: []*Animation{
 			engineFireburst,
@@ -363,30 +165,29 @@ func NewPlayer(curgame *Game) *Player {
 
 func (p *Player) Update() {
 	if p.game.ResolutionChange {
-		p.sprite = objects.ScaleImg(assets.PlayerSprite, p.game.Options.ResolutionMultipler)
-		//p.game.ResolutionChange = false
+		p.scaleSprite()
 	}
 
-	x, y := ebiten.CursorPosition()
-	if ebiten.IsKeyPressed(ebiten.KeyA) {
+	x, y := p.game.input.CursorPosition()
+	if p.game.input.IsKeyPressed(p.game.settings.Keys[ActionLeft]) {
 		p.position.X -= p.params.speed
 		if p.position.X < 0 {
 			p.position.X = 0
 		}
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyD) {
+	if p.game.input.IsKeyPressed(p.game.settings.Keys[ActionRight]) {
 		p.position.X += p.params.speed
 		if p.position.X > p.game.Options.ScreenWidth {
 			p.position.X = p.game.Options.ScreenWidth
 		}
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyW) {
+	if p.game.input.IsKeyPressed(p.game.settings.Keys[ActionUp]) {
 		p.position.Y -= p.params.speed
 		if p.position.Y < 0 {
 			p.position.Y = 0
 		}
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyS) {
+	if p.game.input.IsKeyPressed(p.game.settings.Keys[ActionDown]) {
 		p.position.Y += p.params.speed
 		if p.position.Y > p.game.Options.ScreenHeight {
 			p.position.Y = p.game.Options.ScreenHeight
@@ -439,21 +240,21 @@ func (p *Player) Update() {
 		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.Key1) {
+	if p.game.input.IsKeyPressed(ebiten.Key1) {
 		p.curWeapon = p.weapons[0]
-	} else if ebiten.IsKeyPressed(ebiten.Key2) && len(p.weapons) > 1 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key2) && len(p.weapons) > 1 {
 		p.curWeapon = p.weapons[1]
-	} else if ebiten.IsKeyPressed(ebiten.Key3) && len(p.weapons) > 2 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key3) && len(p.weapons) > 2 {
 		p.curWeapon = p.weapons[2]
-	} else if ebiten.IsKeyPressed(ebiten.Key4) && len(p.weapons) > 3 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key4) && len(p.weapons) > 3 {
 		p.curWeapon = p.weapons[3]
-	} else if ebiten.IsKeyPressed(ebiten.Key5) && len(p.weapons) > 4 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key5) && len(p.weapons) > 4 {
 		p.curWeapon = p.weapons[4]
-	} else if ebiten.IsKeyPressed(ebiten.Key6) && len(p.weapons) > 5 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key6) && len(p.weapons) > 5 {
 		p.curWeapon = p.weapons[5]
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyQ) && len(p.weapons) > 1 {
+	if p.game.input.IsKeyJustPressed(p.game.settings.Keys[ActionNextWeapon]) && len(p.weapons) > 1 {
 		for i := range p.weapons {
 			if i < len(p.weapons)-1 && p.curWeapon == p.weapons[i] {
 				p.curWeapon = p.weapons[(i + 1)]
@@ -465,17 +266,17 @@ func (p *Player) Update() {
 		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.Key7) && len(p.secondaryWeapons) > 0 {
+	if p.game.input.IsKeyPressed(ebiten.Key7) && len(p.secondaryWeapons) > 0 {
 		p.curSecondaryWeapon = p.secondaryWeapons[0]
-	} else if ebiten.IsKeyPressed(ebiten.Key8) && len(p.secondaryWeapons) > 1 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key8) && len(p.secondaryWeapons) > 1 {
 		p.curSecondaryWeapon = p.secondaryWeapons[1]
-	} else if ebiten.IsKeyPressed(ebiten.Key9) && len(p.secondaryWeapons) > 2 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key9) && len(p.secondaryWeapons) > 2 {
 		p.curSecondaryWeapon = p.secondaryWeapons[2]
-	} else if ebiten.IsKeyPressed(ebiten.Key0) && len(p.secondaryWeapons) > 3 {
+	} else if p.game.input.IsKeyPressed(ebiten.Key0) && len(p.secondaryWeapons) > 3 {
 		p.curSecondaryWeapon = p.secondaryWeapons[3]
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyE) && len(p.secondaryWeapons) > 1 {
+	if p.game.input.IsKeyJustPressed(p.game.settings.Keys[ActionNextSecondary]) && len(p.secondaryWeapons) > 1 {
 		for i := range p.secondaryWeapons {
 			if i < len(p.secondaryWeapons)-1 && p.curWeapon == p.secondaryWeapons[i] {
 				p.curSecondaryWeapon = p.secondaryWeapons[(i + 1)]
@@ -488,7 +289,7 @@ func (p *Player) Update() {
 	}
 
 	p.curWeapon.shootCooldown.Update()
-	if p.curWeapon.shootCooldown.IsReady() && (ebiten.IsKeyPressed(ebiten.KeySpace) || ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)) {
+	if p.curWeapon.shootCooldown.IsReady() && (p.game.input.IsKeyPressed(p.game.settings.Keys[ActionFire]) || p.game.input.IsMouseButtonPressed(ebiten.MouseButtonLeft)) {
 		if p.curWeapon.ammo <= 0 {
 			return
 		}
@@ -497,7 +298,7 @@ func (p *Player) Update() {
 	}
 	if p.curSecondaryWeapon != nil {
 		p.curSecondaryWeapon.shootCooldown.Update()
-		if p.curSecondaryWeapon.shootCooldown.IsReady() && ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+		if p.curSecondaryWeapon.shootCooldown.IsReady() && p.game.input.IsMouseButtonPressed(ebiten.MouseButtonRight) {
 			if p.curSecondaryWeapon.ammo <= 0 {
 				return
 			}
@@ -524,3 +325,14 @@ func (p *Player) Collider() image.Rectangle {
 		},
 	}
 }
+
+// respawnAnimations puts the animations the player still owns back into a
+// freshly cleared world.
+func (p *Player) respawnAnimations() {
+	for _, a := range p.animations {
+		if a.name == "shield" && p.shield == nil {
+			continue
+		}
+		p.game.AddAnimation(a)
+	}
+}
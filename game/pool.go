@@ -0,0 +1,63 @@
+package game
+
+import (
+	"astrogame/config"
+	"image"
+	"image/color"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Projectiles and short lived animations are recycled once the world drops
+// them, so heavy fire does not turn into garbage every tick.
+var (
+	projectilePool = sync.Pool{New: func() any { return new(Projectile) }}
+	animationPool  = sync.Pool{New: func() any { return new(Animation) }}
+)
+
+func releaseProjectile(p *Projectile) {
+	*p = Projectile{}
+	projectilePool.Put(p)
+}
+
+// releaseAnimation only takes back animations created by AddEffect, the
+// others may still be referenced by their owner.
+func releaseAnimation(a *Animation) {
+	if !a.pooled {
+		return
+	}
+	*a = Animation{}
+	animationPool.Put(a)
+}
+
+// AddEffect spawns a pooled animation that belongs to the world only, nobody
+// may keep it after it is despawned.
+func (g *Game) AddEffect(position config.Vector, clip string, rotation float64) *Animation {
+	a := animationPool.Get().(*Animation)
+	a.init(position, clip, rotation)
+	a.pooled = true
+	g.AddAnimation(a)
+	return a
+}
+
+type spritesheetKey struct {
+	sheet  *ebiten.Image
+	width  int
+	height int
+}
+
+var spritesheetFrames = map[spritesheetKey][]*ebiten.Image{}
+
+var beamPixel *ebiten.Image
+
+// whitePixel returns a 1x1 white image that beams stretch to their size.
+func whitePixel() *ebiten.Image {
+	if beamPixel == nil {
+		img := ebiten.NewImage(3, 3)
+		img.Fill(color.White)
+		// The centre pixel avoids filtering artifacts at the image borders
+		beamPixel = img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	}
+	return beamPixel
+}
@@ -1,8 +1,6 @@
 package game
 
 import (
-	"astrogame/assets"
-	"astrogame/config"
 	"astrogame/objects"
 	"fmt"
 	"image"
@@ -10,7 +8,6 @@ import (
 	"log"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 )
 
@@ -64,7 +61,7 @@ func NewPlayerProfile(g *Game) *ProfileScreen {
 			Label:   "return in game",
 			vector:  image.Rect(g.Options.ScreenXProfileShift+barStroke+section*7, int(g.Options.ScreenHeight)-g.Options.ScreenYProfileShift, g.Options.ScreenXProfileShift+barStroke+section*7+220, int(g.Options.ScreenHeight)-g.Options.ScreenYProfileShift+28),
 			Action: func(g *Game) error {
-				g.state = config.InGame
+				g.scenes.Pop()
 				return nil
 			},
 		},
@@ -92,144 +89,27 @@ func NewPlayerProfile(g *Game) *ProfileScreen {
 			getter:      g.player.params.GetSpeed,
 			increase:    g.player.params.IncreaseSpeed,
 		},
-		{
-			label:       "Light missile fire rate X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 20,
-			icon:        objects.ScaleImg(assets.MissileSprite, 0.6),
-			getter:      g.player.params.GetLightRocketSpeedUpscale,
-			increase:    g.player.params.IncreaseLightRocketSpeedUpscale,
-		},
-		{
-			label:       "Light missile velocity X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 10,
-			icon:        objects.ScaleImg(assets.MissileSprite, 0.6),
-			getter:      g.player.params.GetLightRocketVelocityMultiplier,
-			increase:    g.player.params.IncreaseLightRocketVelocityMultiplier,
-		},
-		{
-			label:       "Double missile fire rate X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 30,
-			icon:        objects.ScaleImg(assets.DoubleMissileSprite, 0.6),
-			getter:      g.player.params.GetDoubleLightRocketSpeedUpscale,
-			increase:    g.player.params.IncreaseDoubleLightRocketSpeedUpscale,
-		},
-		{
-			label:       "Double missile velocity X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 15,
-			icon:        objects.ScaleImg(assets.DoubleMissileSprite, 0.6),
-			getter:      g.player.params.GetDoubleLightRocketVelocityMultiplier,
-			increase:    g.player.params.IncreaseDoubleLightRocketVelocityMultiplier,
-		},
-		{
-			label:       "Machine gun fire rate X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 35,
-			icon:        objects.ScaleImg(assets.MachineGun, 0.6),
-			getter:      g.player.params.GetMachineGunSpeedUpscale,
-			increase:    g.player.params.IncreaseMachineGunSpeedUpscale,
-		},
-		{
-			label:       "Machine gun projectile velocity X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 20,
-			icon:        objects.ScaleImg(assets.MachineGun, 0.6),
-			getter:      g.player.params.GetMachineGunVelocityMultiplier,
-			increase:    g.player.params.IncreaseMachineGunVelocityMultiplier,
-		},
-		{
-			label:       "Laser canon fire rate X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 35,
-			icon:        objects.ScaleImg(assets.LaserCanon, 0.6),
-			getter:      g.player.params.GetLaserCanonSpeedUpscale,
-			increase:    g.player.params.IncreaseLaserCanonSpeedUpscale,
-		},
-		{
-			label:       "Double laser canon fire rate X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 45,
-			icon:        objects.ScaleImg(assets.DoubleLaserCanon, 0.6),
-			getter:      g.player.params.GetDoubleLaserCanonSpeedUpscale,
-			increase:    g.player.params.IncreaseDoubleLaserCanonSpeedUpscale,
-		},
-		{
-			label:       "Plasma gun fire rate X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 52,
-			icon:        objects.ScaleImg(assets.PlasmaGun, 0.6),
-			getter:      g.player.params.GetPlasmaGunSpeedUpscale,
-			increase:    g.player.params.IncreasePlasmaGunSpeedUpscale,
-		},
-		{
-			label:       "Plasma gun projectile velocity X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 40,
-			icon:        objects.ScaleImg(assets.DoubleLaserCanon, 0.6),
-			getter:      g.player.params.GetPlasmaGunVelocityMultiplier,
-			increase:    g.player.params.IncreasePlasmaGunVelocityMultiplier,
-		},
-		{
-			label:       "Double plasma gun fire rate X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 64,
-			icon:        objects.ScaleImg(assets.PlasmaGun, 0.6),
-			getter:      g.player.params.GetDoublePlasmaGunSpeedUpscale,
-			increase:    g.player.params.IncreaseDoublePlasmaGunSpeedUpscale,
-		},
-		{
-			label:       "Double plasma gun velocity X",
-			barType:     profileScreen.LeftBar,
-			creditsCost: 48,
-			icon:        objects.ScaleImg(assets.PlasmaGun, 0.6),
-			getter:      g.player.params.GetDoublePlasmaGunVelocityMultiplier,
-			increase:    g.player.params.IncreaseDoublePlasmaGunVelocityMultiplier,
-		},
 	}
-	var profileItemsRight = profileItemsType{
-		{
-			label:       "Big Bomb fire rate X",
-			barType:     profileScreen.RightBar,
-			creditsCost: 50,
-			icon:        objects.ScaleImg(assets.BigBomb, 0.6),
-			getter:      g.player.params.GetBigBombSpeedUpscale,
-			increase:    g.player.params.IncreaseBigBombSpeedUpscale,
-		},
-		{
-			label:       "Big Bomb velocity X",
-			barType:     profileScreen.RightBar,
-			creditsCost: 40,
-			icon:        objects.ScaleImg(assets.BigBomb, 0.6),
-			getter:      g.player.params.GetBigBombVelocityMultiplier,
-			increase:    g.player.params.IncreaseBigBombVelocityMultiplier,
-		},
-		{
-			label:       "Cluster mines fire rate X",
-			barType:     profileScreen.RightBar,
-			creditsCost: 50,
-			icon:        objects.ScaleImg(assets.ClusterMines, 0.6),
-			getter:      g.player.params.GetClusterMinesSpeedUpscale,
-			increase:    g.player.params.IncreaseClusterMinesSpeedUpscale,
-		},
-		{
-			label:       "Cluster mines velocity X",
-			barType:     profileScreen.RightBar,
-			creditsCost: 50,
-			icon:        objects.ScaleImg(assets.ClusterMines, 0.6),
-			getter:      g.player.params.GetClusterMinesVelocityMultiplier,
-			increase:    g.player.params.IncreaseClusterMinesVelocityMultiplier,
-		},
-		{
-			label:       "Penta laser fire rate X",
-			barType:     profileScreen.RightBar,
-			creditsCost: 80,
-			icon:        objects.ScaleImg(assets.PentaLaser, 0.6),
-			getter:      g.player.params.GetPentaLaserSpeedUpscale,
-			increase:    g.player.params.IncreasePentaLaserSpeedUpscale,
-		},
+	var profileItemsRight profileItemsType
+	params := g.player.params
+	for _, d := range weapons().defs {
+		for _, u := range d.Upgrades {
+			id := u.id
+			item := profileItemTemplate{
+				label:       u.Label,
+				barType:     profileScreen.LeftBar,
+				creditsCost: u.Cost,
+				icon:        objects.ScaleImg(d.icon(), 0.6),
+				getter:      func() int { return params.UpgradeLevel(id) },
+				increase:    func(t int) { params.IncreaseUpgrade(id, t) },
+			}
+			if d.Slot == slotSecondary {
+				item.barType = profileScreen.RightBar
+				profileItemsRight = append(profileItemsRight, item)
+			} else {
+				profileItemsLeft = append(profileItemsLeft, item)
+			}
+		}
 	}
 	for i, profItem := range profileItemsLeft {
 		prepareMenuItem(i, &profItem, &profileScreen)
@@ -239,12 +119,26 @@ func NewPlayerProfile(g *Game) *ProfileScreen {
 	}
 	return &profileScreen
 }
-func (p *ProfileScreen) Update() {
+
+// Enter remembers the current values, so that upgrades bought on this visit
+// can be taken back.
+func (p *ProfileScreen) Enter() {
+	for _, i := range p.LeftBar.Items {
+		i.UpdatePrevValue(p.Game)
+	}
+	for _, i := range p.RightBar.Items {
+		i.UpdatePrevValue(p.Game)
+	}
+}
+
+func (p *ProfileScreen) Exit() {}
+
+func (p *ProfileScreen) Update() error {
 	for _, w := range p.Game.player.weapons {
-		w.UpdateParams(p.Game.player, w)
+		w.applyParams(p.Game.player)
 	}
 	// Mouse hover on menu items
-	mouseX, mouseY := ebiten.CursorPosition()
+	mouseX, mouseY := p.Game.input.CursorPosition()
 	for _, profileItem := range p.LeftBar.Items {
 		profileItem.UpdateValue(p.Game)
 		for i, menuItem := range profileItem.Buttons {
@@ -276,7 +170,7 @@ func (p *ProfileScreen) Update() {
 		if p.returnButton.Active {
 			p.returnButton.Choosen = true
 		}
-		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if p.Game.input.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 			err := p.returnButton.Action(p.Game)
 			if err != nil {
 				log.Fatal(err)
@@ -285,7 +179,7 @@ func (p *ProfileScreen) Update() {
 	}
 
 	// Mouse click on menu items
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+	if p.Game.input.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		for _, profileItem := range p.LeftBar.Items {
 			for i, menuItem := range profileItem.Buttons {
 				if mouseX >= menuItem.vector.Min.X && mouseX <= menuItem.vector.Max.X && mouseY >= menuItem.vector.Min.Y && mouseY <= menuItem.vector.Max.Y && profileItem.Buttons[i].Active {
@@ -305,12 +199,10 @@ func (p *ProfileScreen) Update() {
 	}
 
 	// Return to game if started
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && p.Game.started {
-		err := ContinueGame(p.Game)
-		if err != nil {
-			log.Fatal(err)
-		}
+	if p.Game.input.IsKeyJustPressed(ebiten.KeyEscape) && p.Game.started {
+		p.Game.scenes.Pop()
 	}
+	return nil
 }
 
 func (p *ProfileScreen) Draw(screen *ebiten.Image) {
@@ -320,6 +212,9 @@ func (p *ProfileScreen) Draw(screen *ebiten.Image) {
 	section := barWidth / 10
 	text.Draw(screen, fmt.Sprintf("Available credits: %v", p.credits), p.Game.Options.ProfileBigFont, int(p.Game.Options.ScreenWidth)/2-200, barStroke+50-2, color.RGBA{0, 0, 0, 255})
 	text.Draw(screen, fmt.Sprintf("Available credits: %v", p.credits), p.Game.Options.ProfileBigFont, int(p.Game.Options.ScreenWidth)/2-200, barStroke+50, color.RGBA{255, 255, 255, 255})
+	if p.Game.pilot != nil {
+		text.Draw(screen, fmt.Sprintf("Pilot: %v", p.Game.pilot.Name), p.Game.Options.ProfileFont, p.Game.Options.ScreenXProfileShift, barStroke+50, color.RGBA{255, 255, 255, 255})
+	}
 	for _, i := range p.LeftBar.Items {
 		text.Draw(screen, fmt.Sprintf("%v", i.Label), p.Game.Options.ProfileFont, i.LabelPos.Min.X+(section*2)-2, i.LabelPos.Min.Y+barStroke-2, color.RGBA{0, 0, 0, 255})
 		text.Draw(screen, fmt.Sprintf("%v", i.Label), p.Game.Options.ProfileFont, i.LabelPos.Min.X+(section*2), i.LabelPos.Min.Y+barStroke, color.RGBA{255, 255, 255, 255})
@@ -0,0 +1,434 @@
+package game
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	replayMagic   = "ASTRORPL"
+	replayVersion = 4
+)
+
+const frameUnfocused = 1 << 0
+
+var replaySpeeds = []int{1, 2, 4, 8}
+
+type ReplayHeader struct {
+	Seed        int64
+	Ship        string
+	DeviceScale float64
+	Resolution  int
+	Keys        Keybindings
+	// Upgrades are the permanent upgrade levels the run started with
+	Upgrades map[string]int
+}
+
+// Replay is the recorded input of a single run. Frames[0] is the input of the
+// tick the run was started on, every following frame is one game tick.
+type Replay struct {
+	Header ReplayHeader
+	Frames []InputState
+}
+
+func (r *Replay) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	rw := &replayWriter{w: bw}
+	rw.bytes([]byte(replayMagic))
+	rw.uvarint(replayVersion)
+	rw.varint(r.Header.Seed)
+	rw.string(r.Header.Ship)
+	rw.uvarint(math.Float64bits(r.Header.DeviceScale))
+	rw.uvarint(uint64(r.Header.Resolution))
+	actions := make([]string, 0, len(r.Header.Keys))
+	for a := range r.Header.Keys {
+		actions = append(actions, string(a))
+	}
+	slices.Sort(actions)
+	rw.uvarint(uint64(len(actions)))
+	for _, a := range actions {
+		rw.string(a)
+		rw.uvarint(uint64(r.Header.Keys[Action(a)]))
+	}
+	upgrades := make([]string, 0, len(r.Header.Upgrades))
+	for id := range r.Header.Upgrades {
+		upgrades = append(upgrades, id)
+	}
+	slices.Sort(upgrades)
+	rw.uvarint(uint64(len(upgrades)))
+	for _, id := range upgrades {
+		rw.string(id)
+		rw.uvarint(uint64(r.Header.Upgrades[id]))
+	}
+	rw.uvarint(uint64(len(r.Frames)))
+	// Identical consecutive frames are stored once together with a repeat count
+	for i := 0; i < len(r.Frames); {
+		run := 1
+		for i+run < len(r.Frames) && equalInput(r.Frames[i], r.Frames[i+run]) {
+			run++
+		}
+		rw.uvarint(uint64(run))
+		rw.frame(r.Frames[i])
+		i += run
+	}
+	if rw.err != nil {
+		return rw.err
+	}
+	err := bw.Flush()
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func ReadReplay(r io.Reader) (*Replay, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	defer zr.Close()
+	rr := &replayReader{r: bufio.NewReader(zr)}
+	magic := rr.bytes(len(replayMagic))
+	if rr.err == nil && string(magic) != replayMagic {
+		return nil, errors.New("replay: not a replay file")
+	}
+	version := rr.uvarint()
+	if rr.err == nil && (version == 0 || version > replayVersion) {
+		return nil, fmt.Errorf("replay: unsupported version %d", version)
+	}
+	rr.version = version
+	replay := &Replay{}
+	replay.Header.Seed = rr.varint()
+	replay.Header.Ship = rr.string()
+	replay.Header.DeviceScale = math.Float64frombits(rr.uvarint())
+	replay.Header.Resolution = int(rr.uvarint())
+	// Replays before version 3 were always recorded with the default keys
+	replay.Header.Keys = DefaultKeybindings()
+	if version >= 3 {
+		n := rr.uvarint()
+		if rr.err == nil && n > 64 {
+			return nil, errors.New("replay: too many keybindings")
+		}
+		for i := uint64(0); rr.err == nil && i < n; i++ {
+			a := Action(rr.string())
+			replay.Header.Keys[a] = ebiten.Key(rr.uvarint())
+		}
+	}
+	replay.Header.Upgrades = map[string]int{}
+	if version >= 4 {
+		n := rr.uvarint()
+		if rr.err == nil && n > 64 {
+			return nil, errors.New("replay: too many upgrades")
+		}
+		for i := uint64(0); rr.err == nil && i < n; i++ {
+			id := rr.string()
+			replay.Header.Upgrades[id] = int(rr.uvarint())
+		}
+	}
+	total := rr.uvarint()
+	for rr.err == nil && uint64(len(replay.Frames)) < total {
+		run := rr.uvarint()
+		frame := rr.frame()
+		if rr.err == nil && (run == 0 || run > total-uint64(len(replay.Frames))) {
+			return nil, errors.New("replay: corrupt frame data")
+		}
+		for i := uint64(0); rr.err == nil && i < run; i++ {
+			replay.Frames = append(replay.Frames, frame)
+		}
+	}
+	if rr.err != nil {
+		return nil, fmt.Errorf("replay: %w", rr.err)
+	}
+	return replay, nil
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadReplay(f)
+}
+
+func (r *Replay) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = r.Write(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func equalInput(a, b InputState) bool {
+	return a.CursorX == b.CursorX && a.CursorY == b.CursorY && a.Unfocused == b.Unfocused && slices.Equal(a.Keys, b.Keys) && slices.Equal(a.Buttons, b.Buttons)
+}
+
+type replayWriter struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (rw *replayWriter) bytes(b []byte) {
+	if rw.err == nil {
+		_, rw.err = rw.w.Write(b)
+	}
+}
+
+func (rw *replayWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(rw.buf[:], v)
+	rw.bytes(rw.buf[:n])
+}
+
+func (rw *replayWriter) varint(v int64) {
+	n := binary.PutVarint(rw.buf[:], v)
+	rw.bytes(rw.buf[:n])
+}
+
+func (rw *replayWriter) string(s string) {
+	rw.uvarint(uint64(len(s)))
+	rw.bytes([]byte(s))
+}
+
+func (rw *replayWriter) frame(s InputState) {
+	rw.varint(int64(s.CursorX))
+	rw.varint(int64(s.CursorY))
+	var buttons uint64
+	for _, b := range s.Buttons {
+		buttons |= 1 << uint(b)
+	}
+	rw.uvarint(buttons)
+	var flags uint64
+	if s.Unfocused {
+		flags |= frameUnfocused
+	}
+	rw.uvarint(flags)
+	rw.uvarint(uint64(len(s.Keys)))
+	for _, k := range s.Keys {
+		rw.uvarint(uint64(k))
+	}
+}
+
+type replayReader struct {
+	r       *bufio.Reader
+	version uint64
+	err     error
+}
+
+func (rr *replayReader) bytes(n int) []byte {
+	if rr.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	_, rr.err = io.ReadFull(rr.r, b)
+	return b
+}
+
+func (rr *replayReader) uvarint() uint64 {
+	if rr.err != nil {
+		return 0
+	}
+	var v uint64
+	v, rr.err = binary.ReadUvarint(rr.r)
+	return v
+}
+
+func (rr *replayReader) varint() int64 {
+	if rr.err != nil {
+		return 0
+	}
+	var v int64
+	v, rr.err = binary.ReadVarint(rr.r)
+	return v
+}
+
+func (rr *replayReader) string() string {
+	n := rr.uvarint()
+	if rr.err == nil && n > 256 {
+		rr.err = errors.New("string too long")
+	}
+	return string(rr.bytes(int(n)))
+}
+
+func (rr *replayReader) frame() InputState {
+	var s InputState
+	s.CursorX = int(rr.varint())
+	s.CursorY = int(rr.varint())
+	buttons := rr.uvarint()
+	for b := 0; b < 64; b++ {
+		if buttons&(1<<uint(b)) != 0 {
+			s.Buttons = append(s.Buttons, ebiten.MouseButton(b))
+		}
+	}
+	// Version 1 frames have no flags
+	if rr.version >= 2 {
+		flags := rr.uvarint()
+		s.Unfocused = flags&frameUnfocused != 0
+	}
+	keys := rr.uvarint()
+	if rr.err == nil && keys > uint64(ebiten.KeyMax)+1 {
+		rr.err = errors.New("too many keys in a frame")
+	}
+	for i := uint64(0); rr.err == nil && i < keys; i++ {
+		s.Keys = append(s.Keys, ebiten.Key(rr.uvarint()))
+	}
+	return s
+}
+
+// startRecording begins a new replay for the run that starts on this tick.
+func (g *Game) startRecording(ship *Ship) {
+	if !g.recordReplays {
+		return
+	}
+	g.recording = &Replay{
+		Header: ReplayHeader{
+			Seed:        g.seed,
+			Ship:        ship.Name,
+			DeviceScale: g.Options.DeviceScale,
+			Resolution:  g.Options.ResolutionWidth,
+			Keys:        g.settings.Keys,
+			Upgrades:    g.metaLevels(),
+		},
+		Frames: []InputState{g.input.State()},
+	}
+}
+
+// finishRecording writes the replay of the current run to the replays folder.
+func (g *Game) finishRecording() {
+	if g.recording == nil {
+		return
+	}
+	replay := g.recording
+	g.recording = nil
+	path, err := dataPath("replays", fmt.Sprintf("%s-%d.rpl", time.Now().Format("20060102-150405"), replay.Header.Seed))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	err = replay.Save(path)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Shutdown flushes everything that has to survive the process exit.
+func (g *Game) Shutdown() {
+	g.finishRecording()
+	g.saveRun()
+	if g.pilot != nil {
+		g.savePilots()
+	}
+}
+
+type replayPlayer struct {
+	replay *Replay
+	pos    int
+	paused bool
+	speed  int
+}
+
+func (rp *replayPlayer) Next(tick int) InputState {
+	if rp.Finished() {
+		return InputState{}
+	}
+	s := rp.replay.Frames[rp.pos]
+	rp.pos++
+	return s
+}
+
+func (rp *replayPlayer) Finished() bool {
+	return rp.pos >= len(rp.replay.Frames)
+}
+
+// NewReplayGame rebuilds the run recorded in the replay file and plays it
+// back through the regular game loop.
+func NewReplayGame(path string) (*Game, error) {
+	replay, err := LoadReplay(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(replay.Frames) == 0 {
+		return nil, errors.New("replay: no frames recorded")
+	}
+	ship := ShipByName(replay.Header.Ship)
+	if ship == nil {
+		return nil, fmt.Errorf("replay: unknown ship %q", replay.Header.Ship)
+	}
+	player := &replayPlayer{
+		replay: replay,
+		pos:    1,
+		speed:  1,
+	}
+	settings := DefaultSettings()
+	settings.Resolution = replay.Header.Resolution
+	settings.Keys = replay.Header.Keys
+	g := newGame(replay.Header.DeviceScale, player, replay.Header.Seed, settings)
+	g.Reset()
+	g.ResolutionChange = false
+	g.replayUpgrades = replay.Header.Upgrades
+	g.input.Update(replay.Frames[0])
+	g.StartRun(ship)
+	g.playback = player
+	return g, nil
+}
+
+// Update reads the playback controls from the real keyboard and advances
+// the recorded run accordingly.
+func (rp *replayPlayer) Update(g *Game) error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		return ebiten.Termination
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		rp.paused = !rp.paused
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		idx := slices.Index(replaySpeeds, rp.speed)
+		rp.speed = replaySpeeds[(idx+1)%len(replaySpeeds)]
+	}
+	ticks := rp.speed
+	if rp.paused {
+		ticks = 0
+		if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
+			ticks = 1
+		}
+	}
+	for i := 0; i < ticks && !rp.Finished(); i++ {
+		err := g.update()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rp *replayPlayer) Draw(g *Game, screen *ebiten.Image) {
+	status := fmt.Sprintf("REPLAY x%d", rp.speed)
+	if rp.paused {
+		status = "REPLAY paused"
+	}
+	if rp.Finished() {
+		status = "REPLAY finished"
+	}
+	msg := fmt.Sprintf("%s  %d/%d  [space] pause  [right] step  [F] speed  [esc] quit", status, rp.pos, len(rp.replay.Frames))
+	text.Draw(screen, msg, g.Options.SmallFont, 20, int(g.Options.ScreenHeight)-20, color.White)
+}
@@ -0,0 +1,346 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+
+	"astrogame/assets"
+	"astrogame/config"
+)
+
+const (
+	saveFileName = "save.json"
+	saveVersion  = 2
+)
+
+// saveMigrations upgrade the raw JSON of an older save by one version, the
+// function at index v turns a version v save into a version v+1 save.
+// Player params that a migration doesn't mention keep the values of a fresh
+// run when the save is restored.
+var saveMigrations = map[int]func(raw map[string]json.RawMessage) error{
+	1: migrateWeaponUpgrades,
+}
+
+// legacyUpgradeWeapons maps the weapon prefix of the upgrade fields version 1
+// saves kept in the params to the weapon names.
+var legacyUpgradeWeapons = map[string]string{
+	"LightRocket":       config.LightRocket,
+	"AutoLightRocket":   config.AutoLightRocket,
+	"DoubleLightRocket": config.DoubleLightRocket,
+	"LaserCanon":        config.LaserCanon,
+	"DoubleLaserCanon":  config.DoubleLaserCanon,
+	"ClusterMines":      config.ClusterMines,
+	"BigBomb":           config.BigBomb,
+	"MachineGun":        config.MachineGun,
+	"DoubleMachineGun":  config.DoubleMachineGun,
+	"PlasmaGun":         config.PlasmaGun,
+	"DoublePlasmaGun":   config.DoublePlasmaGun,
+	"PentaLaser":        config.PentaLaser,
+}
+
+// migrateWeaponUpgrades turns the upgrade fields of every weapon into the
+// upgrade levels of version 2.
+func migrateWeaponUpgrades(raw map[string]json.RawMessage) error {
+	if len(raw["params"]) == 0 {
+		return nil
+	}
+	var params map[string]json.RawMessage
+	err := json.Unmarshal(raw["params"], &params)
+	if err != nil {
+		return err
+	}
+	upgrades := map[string]int{}
+	for key, value := range params {
+		weapon, stat, ok := "", "", false
+		if prefix, found := strings.CutSuffix(key, "SpeedUpscale"); found {
+			weapon, ok = legacyUpgradeWeapons[prefix]
+			stat = statFireRate
+		} else if prefix, found := strings.CutSuffix(key, "VelocityMultiplier"); found {
+			weapon, ok = legacyUpgradeWeapons[prefix]
+			stat = statVelocity
+		}
+		if !ok {
+			continue
+		}
+		delete(params, key)
+		var level float64
+		err = json.Unmarshal(value, &level)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		// Velocity multipliers of a fresh version 1 run started at one
+		if stat == statVelocity && weapon != config.DoubleMachineGun {
+			level--
+		}
+		if level > 0 {
+			upgrades[weapon+"."+stat] = int(level)
+		}
+	}
+	params["Upgrades"], err = json.Marshal(upgrades)
+	if err != nil {
+		return err
+	}
+	raw["params"], err = json.Marshal(params)
+	return err
+}
+
+type savedWeapon struct {
+	Name string `json:"name"`
+	Ammo int    `json:"ammo"`
+}
+
+// RunSave is the state of a run at the start of its current stage.
+type RunSave struct {
+	Version          int             `json:"version"`
+	Seed             int64           `json:"seed"`
+	Ship             string          `json:"ship"`
+	Level            int             `json:"level"`
+	Stage            int             `json:"stage"`
+	Score            int             `json:"score"`
+	Credits          int             `json:"credits"`
+	Speed            float64         `json:"speed"`
+	Shield           int             `json:"shield"`
+	Params           json.RawMessage `json:"params"`
+	Weapons          []savedWeapon   `json:"weapons"`
+	CurWeapon        int             `json:"curWeapon"`
+	SecondaryWeapons []savedWeapon   `json:"secondaryWeapons"`
+	CurSecondary     int             `json:"curSecondary"`
+	Ticks            int             `json:"ticks"`
+}
+
+func ReadRunSave(data []byte) (*RunSave, error) {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("save: %w", err)
+	}
+	var version int
+	err = json.Unmarshal(raw["version"], &version)
+	if err != nil {
+		return nil, fmt.Errorf("save: version: %w", err)
+	}
+	if version < 1 || version > saveVersion {
+		return nil, fmt.Errorf("save: unsupported version %d", version)
+	}
+	for v := version; v < saveVersion; v++ {
+		migrate, ok := saveMigrations[v]
+		if !ok {
+			return nil, fmt.Errorf("save: no migration from version %d", v)
+		}
+		err = migrate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("save: migrating version %d: %w", v, err)
+		}
+	}
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("save: %w", err)
+	}
+	save := &RunSave{}
+	err = json.Unmarshal(data, save)
+	if err != nil {
+		return nil, fmt.Errorf("save: %w", err)
+	}
+	save.Version = saveVersion
+	return save, nil
+}
+
+func LoadRunSave(path string) (*RunSave, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReadRunSave(data)
+}
+
+func (s *RunSave) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Written next to the old save first so a crash never leaves half a file
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func saveWeapons(weapons []*Weapon, cur *Weapon) ([]savedWeapon, int) {
+	saved := make([]savedWeapon, 0, len(weapons))
+	for _, w := range weapons {
+		saved = append(saved, savedWeapon{Name: w.projectile.wType.WeaponName, Ammo: w.ammo})
+	}
+	return saved, max(slices.Index(weapons, cur), 0)
+}
+
+// newRunSave captures the run as it was when the current stage began.
+func (g *Game) newRunSave() (*RunSave, error) {
+	c := &g.checkpoint
+	data, err := json.Marshal(&c.params)
+	if err != nil {
+		return nil, err
+	}
+	return &RunSave{
+		Version:          saveVersion,
+		Seed:             g.seed,
+		Ship:             g.choosenStartShip.Name,
+		Level:            g.curLevel.LevelId,
+		Stage:            c.stage.StageId,
+		Score:            c.score,
+		Credits:          c.credits,
+		Speed:            c.speed,
+		Shield:           c.shield,
+		Params:           data,
+		Weapons:          c.weapons,
+		CurWeapon:        c.curWeapon,
+		SecondaryWeapons: c.secondaryWeapons,
+		CurSecondary:     c.curSecondary,
+		Ticks:            c.ticks,
+	}, nil
+}
+
+// saveRun writes the current run to the save file. Nothing is written while
+// no run is in progress.
+func (g *Game) saveRun() {
+	if !g.persist || g.choosenStartShip == nil {
+		return
+	}
+	save, err := g.newRunSave()
+	if err == nil {
+		var path string
+		path, err = g.pilot.dataPath(saveFileName)
+		if err == nil {
+			err = save.Save(path)
+		}
+	}
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// deleteRunSave removes the save once its run is over.
+func (g *Game) deleteRunSave() {
+	if !g.persist {
+		return
+	}
+	path, err := g.pilot.dataPath(saveFileName)
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Println(err)
+	}
+}
+
+func (g *Game) hasRunSave() bool {
+	path, err := g.pilot.dataPath(saveFileName)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// ContinueSavedRun rebuilds the saved run and enters it at the start of the
+// saved stage. The game is left untouched when the save can't be used.
+func (g *Game) ContinueSavedRun() error {
+	path, err := g.pilot.dataPath(saveFileName)
+	if err != nil {
+		return err
+	}
+	save, err := LoadRunSave(path)
+	if err != nil {
+		return err
+	}
+	ship := ShipByName(save.Ship)
+	if ship == nil {
+		return fmt.Errorf("save: unknown ship %q", save.Ship)
+	}
+	params := PlayerParams{}
+	if len(save.Params) > 0 {
+		err = json.Unmarshal(save.Params, &params)
+		if err != nil {
+			return fmt.Errorf("save: params: %w", err)
+		}
+	}
+	rng := rand.New(rand.NewSource(save.Seed))
+	levels := GenerateLevels(rng)
+	if save.Level < 0 || save.Level >= len(levels) || save.Stage < 0 || save.Stage >= len(levels[save.Level].Stages) {
+		return fmt.Errorf("save: no stage %d in level %d", save.Stage, save.Level)
+	}
+
+	g.world.Clear()
+	g.finishRecording()
+	g.seed = save.Seed
+	g.rng = rng
+	g.levels = levels
+	g.curLevel = levels[save.Level]
+	g.CurStage = &g.curLevel.Stages[save.Stage]
+	g.CurWave = &g.CurStage.Waves[0]
+	g.bgImage = g.curLevel.BgImg
+
+	g.player = NewPlayer(g)
+	g.equipShip(ship)
+	g.player.restore(save)
+	g.score = save.Score
+	g.started = false
+	g.profile = NewPlayerProfile(g)
+	g.profile.credits = save.Credits
+	g.resetRunTimers()
+	g.runTicks = save.Ticks
+	g.beginStage()
+	g.scenes.Switch(g.play)
+	g.publishWaveStarted()
+	return nil
+}
+
+// restore puts the saved params and weapons on a player that just got its
+// ship. Params missing from the save keep the values of a fresh run.
+func (p *Player) restore(save *RunSave) {
+	ship := p.params.Ship
+	if len(save.Params) > 0 {
+		_ = json.Unmarshal(save.Params, p.params)
+	}
+	p.params.Ship = ship
+	if save.Speed > 0 {
+		p.params.speed = save.Speed
+	}
+
+	if len(save.Weapons) > 0 {
+		p.curWeapon.ammo = save.Weapons[0].Ammo
+		p.weapons = append(p.weapons, restoreWeapons(save.Weapons[1:], p)...)
+		if save.CurWeapon < len(p.weapons) {
+			p.curWeapon = p.weapons[save.CurWeapon]
+		}
+	}
+	p.secondaryWeapons = restoreWeapons(save.SecondaryWeapons, p)
+	if len(p.secondaryWeapons) > 0 {
+		p.curSecondaryWeapon = p.secondaryWeapons[min(save.CurSecondary, len(p.secondaryWeapons)-1)]
+	}
+	if save.Shield > 0 {
+		p.addShield(save.Shield, assets.ShieldSprite)
+	}
+}
+
+func restoreWeapons(saved []savedWeapon, p *Player) []*Weapon {
+	var weapons []*Weapon
+	for _, w := range saved {
+		weapon := NewWeapon(w.Name, p)
+		if weapon == nil {
+			log.Printf("save: dropping unknown weapon %q", w.Name)
+			continue
+		}
+		weapon.ammo = w.Ammo
+		weapons = append(weapons, weapon)
+	}
+	return weapons
+}
@@ -0,0 +1,205 @@
+package game
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const fadeTicks = 10
+
+// Scene is one screen of the game. Enter is called when the scene is put on
+// the stack and Exit when it is taken off.
+type Scene interface {
+	Update() error
+	Draw(screen *ebiten.Image)
+	Enter()
+	Exit()
+}
+
+// overlay is implemented by scenes that are drawn on top of the frozen scene
+// below them instead of replacing it.
+type overlay interface {
+	Overlay() bool
+}
+
+// sceneHooks gives a scene empty Enter and Exit hooks.
+type sceneHooks struct{}
+
+func (sceneHooks) Enter() {}
+func (sceneHooks) Exit()  {}
+
+// SceneManager keeps the stack of scenes, only the top one is updated.
+// Every change of the stack fades the screen out, applies the change and
+// fades back in.
+type SceneManager struct {
+	stack   []Scene
+	pending func()
+	fadeOut bool
+	fade    int
+}
+
+func (m *SceneManager) Top() Scene {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	return m.stack[len(m.stack)-1]
+}
+
+// Push puts s on top of the current scene.
+func (m *SceneManager) Push(s Scene) {
+	m.change(func() {
+		m.push(s)
+	})
+}
+
+// Pop returns to the scene below the top one.
+func (m *SceneManager) Pop() {
+	m.change(func() {
+		m.pop()
+	})
+}
+
+// Switch replaces the whole stack with s.
+func (m *SceneManager) Switch(s Scene) {
+	m.change(func() {
+		for len(m.stack) > 0 {
+			m.pop()
+		}
+		m.push(s)
+	})
+}
+
+func (m *SceneManager) push(s Scene) {
+	m.stack = append(m.stack, s)
+	s.Enter()
+}
+
+func (m *SceneManager) pop() {
+	top := m.Top()
+	if top == nil {
+		return
+	}
+	m.stack = m.stack[:len(m.stack)-1]
+	top.Exit()
+}
+
+func (m *SceneManager) change(apply func()) {
+	// A change requested while another one is still fading out must not be
+	// lost, so the older one is applied right away
+	if m.pending != nil {
+		m.pending()
+	}
+	m.pending = apply
+	m.fadeOut = true
+}
+
+func (m *SceneManager) Update() error {
+	if m.fadeOut {
+		m.fade++
+		if m.fade < fadeTicks {
+			return nil
+		}
+		apply := m.pending
+		m.pending = nil
+		m.fadeOut = false
+		apply()
+	}
+	if m.fade > 0 {
+		m.fade--
+	}
+	if top := m.Top(); top != nil {
+		return top.Update()
+	}
+	return nil
+}
+
+func (m *SceneManager) Draw(screen *ebiten.Image) {
+	first := len(m.stack) - 1
+	for first > 0 {
+		o, ok := m.stack[first].(overlay)
+		if !ok || !o.Overlay() {
+			break
+		}
+		first--
+	}
+	for i := first; i >= 0 && i < len(m.stack); i++ {
+		m.stack[i].Draw(screen)
+	}
+	if m.fade > 0 {
+		bounds := screen.Bounds()
+		alpha := uint8(255 * m.fade / fadeTicks)
+		vector.DrawFilledRect(screen, 0, 0, float32(bounds.Dx()), float32(bounds.Dy()), color.RGBA{0, 0, 0, alpha}, false)
+	}
+}
+
+// playScene is the running game itself.
+type playScene struct {
+	sceneHooks
+	game *Game
+}
+
+func (s *playScene) Enter() {
+	g := s.game
+	if !g.started {
+		g.started = true
+		g.menu.updateItems()
+	}
+}
+
+func (s *playScene) Update() error {
+	g := s.game
+	if g.input.IsKeyJustPressed(g.settings.Keys[ActionPause]) || g.input.Unfocused() {
+		g.scenes.Push(g.pauseMenu)
+		return nil
+	}
+
+	if g.input.IsKeyJustPressed(g.settings.Keys[ActionProfile]) {
+		g.scenes.Push(g.profile)
+	}
+
+	g.MoveBgPosition()
+	g.Step()
+	return nil
+}
+
+func (s *playScene) Draw(screen *ebiten.Image) {
+	g := s.game
+	g.DrawBg(screen)
+
+	g.player.Draw(screen)
+
+	g.world.beamAnimations.Each(func(ba *BeamAnimation) {
+		ba.Draw(screen)
+	})
+
+	g.world.enemies.Each(func(e *Enemy) {
+		e.Draw(screen)
+	})
+
+	g.world.bosses.Each(func(b *Boss) {
+		b.Draw(screen)
+	})
+
+	g.world.meteors.Each(func(m *Meteor) {
+		m.Draw(screen)
+	})
+
+	g.world.projectiles.Each(func(p *Projectile) {
+		p.Draw(screen)
+	})
+
+	g.world.enemyProjectiles.Each(func(p *Projectile) {
+		p.Draw(screen)
+	})
+
+	g.world.items.Each(func(i *Item) {
+		i.Draw(screen)
+	})
+
+	g.world.animations.Each(func(a *Animation) {
+		a.Draw(screen)
+	})
+	g.drawUI(screen)
+}
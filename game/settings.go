@@ -0,0 +1,239 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"astrogame/config"
+)
+
+const (
+	settingsFileName = "settings.json"
+	settingsVersion  = 1
+)
+
+// settingsMigrations upgrade the raw JSON of older settings by one version,
+// the function at index v turns version v settings into version v+1.
+var settingsMigrations = map[int]func(raw map[string]json.RawMessage) error{}
+
+var resolutions = []int{config.ScreenWidth1024X768, config.ScreenWidth1920x1080}
+
+// Action is something the player can bind a key to.
+type Action string
+
+const (
+	ActionLeft          Action = "left"
+	ActionRight         Action = "right"
+	ActionUp            Action = "up"
+	ActionDown          Action = "down"
+	ActionFire          Action = "fire"
+	ActionNextWeapon    Action = "nextWeapon"
+	ActionNextSecondary Action = "nextSecondary"
+	ActionPause         Action = "pause"
+	ActionProfile       Action = "profile"
+)
+
+// Keybindings maps every action to its key.
+type Keybindings map[Action]ebiten.Key
+
+func DefaultKeybindings() Keybindings {
+	return Keybindings{
+		ActionLeft:          ebiten.KeyA,
+		ActionRight:         ebiten.KeyD,
+		ActionUp:            ebiten.KeyW,
+		ActionDown:          ebiten.KeyS,
+		ActionFire:          ebiten.KeySpace,
+		ActionNextWeapon:    ebiten.KeyQ,
+		ActionNextSecondary: ebiten.KeyE,
+		ActionPause:         ebiten.KeyEscape,
+		ActionProfile:       ebiten.KeyP,
+	}
+}
+
+// UnmarshalJSON skips keys with unknown names instead of failing, validate
+// puts the default back for them.
+func (kb *Keybindings) UnmarshalJSON(data []byte) error {
+	var names map[Action]string
+	err := json.Unmarshal(data, &names)
+	if err != nil {
+		return err
+	}
+	*kb = Keybindings{}
+	for a, name := range names {
+		var k ebiten.Key
+		err := k.UnmarshalText([]byte(name))
+		if err != nil {
+			log.Printf("settings: %q: %v", a, err)
+			continue
+		}
+		(*kb)[a] = k
+	}
+	return nil
+}
+
+// Settings is everything the player chose in the options, kept between
+// sessions.
+type Settings struct {
+	Version    int         `json:"version"`
+	Resolution int         `json:"resolution"`
+	Fullscreen bool        `json:"fullscreen"`
+	Volume     float64     `json:"volume"`
+	Keys       Keybindings `json:"keys"`
+	// MetaProgression lets the pilot earn medals and start runs with the
+	// upgrades bought with them
+	MetaProgression bool `json:"metaProgression"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Version:         settingsVersion,
+		Resolution:      config.ScreenWidth1024X768,
+		Volume:          1,
+		Keys:            DefaultKeybindings(),
+		MetaProgression: true,
+	}
+}
+
+// ReadSettings decodes the settings on top of the defaults, so that a field
+// missing from the file keeps its default.
+func ReadSettings(data []byte) (Settings, error) {
+	s := DefaultSettings()
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return s, fmt.Errorf("settings: %w", err)
+	}
+	var version int
+	err = json.Unmarshal(raw["version"], &version)
+	if err != nil {
+		return s, fmt.Errorf("settings: version: %w", err)
+	}
+	if version < 1 || version > settingsVersion {
+		return s, fmt.Errorf("settings: unsupported version %d", version)
+	}
+	for v := version; v < settingsVersion; v++ {
+		migrate, ok := settingsMigrations[v]
+		if !ok {
+			return s, fmt.Errorf("settings: no migration from version %d", v)
+		}
+		err = migrate(raw)
+		if err != nil {
+			return s, fmt.Errorf("settings: migrating version %d: %w", v, err)
+		}
+	}
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return s, fmt.Errorf("settings: %w", err)
+	}
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return DefaultSettings(), fmt.Errorf("settings: %w", err)
+	}
+	s.Version = settingsVersion
+	s.validate()
+	return s, nil
+}
+
+// validate replaces every value the game can't use with its default.
+func (s *Settings) validate() {
+	def := DefaultSettings()
+	valid := false
+	for _, r := range resolutions {
+		valid = valid || s.Resolution == r
+	}
+	if !valid {
+		log.Printf("settings: unsupported resolution %d", s.Resolution)
+		s.Resolution = def.Resolution
+	}
+	if s.Volume < 0 || s.Volume > 1 {
+		log.Printf("settings: volume %v out of range", s.Volume)
+		s.Volume = def.Volume
+	}
+	keys := Keybindings{}
+	used := map[ebiten.Key]Action{}
+	for a, k := range def.Keys {
+		if bound, ok := s.Keys[a]; ok {
+			k = bound
+		}
+		keys[a] = k
+		if other, ok := used[k]; ok {
+			log.Printf("settings: %q and %q share a key, using the default keys", a, other)
+			s.Keys = def.Keys
+			return
+		}
+		used[k] = a
+	}
+	s.Keys = keys
+}
+
+// LoadSettings reads the settings file of the pilot. A missing or broken file
+// gives the default settings.
+func LoadSettings(p *Pilot) Settings {
+	path, err := p.dataPath(settingsFileName)
+	if err != nil {
+		log.Println(err)
+		return DefaultSettings()
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultSettings()
+	}
+	if err != nil {
+		log.Println(err)
+		return DefaultSettings()
+	}
+	s, err := ReadSettings(data)
+	if err != nil {
+		log.Println(err)
+		return DefaultSettings()
+	}
+	return s
+}
+
+// applySettings switches the running game over to s.
+func (g *Game) applySettings(s Settings) {
+	g.settings = s
+	if s.Resolution != g.Options.ResolutionWidth {
+		g.setResolution(s.Resolution)
+		ebiten.SetWindowSize(int(g.Options.ScreenWidth), int(g.Options.ScreenHeight))
+	}
+	if s.Fullscreen != g.Options.Fullscreen {
+		g.Options.Fullscreen = s.Fullscreen
+		ebiten.SetFullscreen(s.Fullscreen)
+	}
+}
+
+func (s Settings) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// saveSettings stores the current options in the settings file.
+func (g *Game) saveSettings() {
+	if !g.persist {
+		return
+	}
+	g.settings.Resolution = g.Options.ResolutionWidth
+	g.settings.Fullscreen = g.Options.Fullscreen
+	path, err := g.pilot.dataPath(settingsFileName)
+	if err == nil {
+		err = g.settings.Save(path)
+	}
+	if err != nil {
+		log.Println(err)
+	}
+}
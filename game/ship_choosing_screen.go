@@ -1,46 +1,54 @@
 package game
 
 import (
-	"astrogame/config"
 	"fmt"
 	"image"
 	"image/color"
 	"log"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/math/fixed"
 )
 
 type shipChoosingScreen struct {
+	sceneHooks
 	Game         *Game
 	Items        []*shipMenuItem
 	returnButton *MenuItem
 }
 type shipMenuItem struct {
-	Ship     *Ship
+	Ship     *ShipDef
 	MenuItem *MenuItem
 }
 
 func NewShipChoosingScreen(g *Game) *shipChoosingScreen {
-	ships := []*Ship{AngryOcelot, MightyOrca, ShadyWeasel}
+	ships := Ships()
 	var shipChoosingScreen shipChoosingScreen
 	var menuItems []*shipMenuItem
 	for _, ship := range ships {
 		menuItems = append(menuItems, makeShipMenuItem(ship))
 	}
 	menuItems[0].MenuItem.Choosen = true
-	strokeX := ships[0].Sprite.Bounds().Dx() / 2
-	shiftX := (int(g.Options.ScreenWidth) - (len(ships)*ships[0].Sprite.Bounds().Dx() + strokeX*(len(ships)-1))) / 2
-	for i, item := range menuItems {
-		cellWidth := item.Ship.Sprite.Bounds().Dx()
-		cellHeight := item.Ship.Sprite.Bounds().Dy()
+	// Every ship of the catalog gets a cell as wide as its sprite, with half
+	// of that as a gap before the next one
+	rowWidth := 0
+	for i, ship := range ships {
+		rowWidth += ship.Sprite.img.Bounds().Dx()
+		if i < len(ships)-1 {
+			rowWidth += ship.Sprite.img.Bounds().Dx() / 2
+		}
+	}
+	shiftX := (int(g.Options.ScreenWidth) - rowWidth) / 2
+	for _, item := range menuItems {
+		cellWidth := item.Ship.Sprite.img.Bounds().Dx()
+		cellHeight := item.Ship.Sprite.img.Bounds().Dy()
 		stroke := cellWidth / 2
 		item.MenuItem.vector = image.Rectangle{
-			Min: image.Point{X: shiftX + (cellWidth+stroke)*i, Y: int(g.Options.ScreenHeight / 3)},
-			Max: image.Point{X: shiftX + (cellWidth+stroke)*i + cellWidth, Y: int(g.Options.ScreenHeight/3) + cellHeight},
+			Min: image.Point{X: shiftX, Y: int(g.Options.ScreenHeight / 3)},
+			Max: image.Point{X: shiftX + cellWidth, Y: int(g.Options.ScreenHeight/3) + cellHeight},
 		}
+		shiftX += cellWidth + stroke
 	}
 	var dot fixed.Point26_6
 	glifImg, _, _, _, _ := g.Options.ScoreFont.Glyph(dot, 'a')
@@ -60,15 +68,26 @@ func NewShipChoosingScreen(g *Game) *shipChoosingScreen {
 			Max: image.Point{X: fontShiftX + charWidth*chars, Y: int(g.Options.ScreenHeight) - g.Options.ScreenYProfileShift + charHeight},
 		},
 		Action: func(g *Game) error {
-			g.state = config.MainMenu
+			g.scenes.Switch(g.menu)
 			return nil
 		},
 	}
 	return &shipChoosingScreen
 }
 
-func (scs *shipChoosingScreen) Update() {
+// Enter locks the ships the current pilot hasn't earned yet.
+func (scs *shipChoosingScreen) Enter() {
+	choosen := false
+	for _, i := range scs.Items {
+		i.MenuItem.Active = scs.Game.shipUnlocked(i.Ship)
+		i.MenuItem.Choosen = i.MenuItem.Active && !choosen
+		choosen = choosen || i.MenuItem.Choosen
+	}
+}
+
+func (scs *shipChoosingScreen) Update() error {
 	scs.shipChoosingMenuUpdate()
+	return nil
 }
 
 func (scs *shipChoosingScreen) Draw(screen *ebiten.Image) {
@@ -80,7 +99,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuUpdate() {
 	Items := scs.Items
 	g := scs.Game
 	// Keyboard arrows input handling
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowRight) {
 		for i := 0; i < len(Items); i++ {
 			if Items[i].MenuItem.Choosen && i < len(Items)-1 {
 				if Items[i+1].MenuItem.Active {
@@ -95,7 +114,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuUpdate() {
 			continue
 		}
 	}
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+	if g.input.IsKeyJustPressed(ebiten.KeyArrowLeft) {
 		for i := len(Items) - 1; i >= 0; i-- {
 			if Items[i].MenuItem.Choosen && i > 0 {
 				if Items[i-1].MenuItem.Active {
@@ -112,7 +131,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuUpdate() {
 	}
 
 	// Choose menu item
-	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+	if g.input.IsKeyJustPressed(ebiten.KeyEnter) {
 		for i := 0; i < len(Items); i++ {
 			if Items[i].MenuItem.Choosen {
 				_ = Items[i].MenuItem.Action(g)
@@ -122,7 +141,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuUpdate() {
 	}
 
 	// Mouse hover on menu items
-	mouseX, mouseY := ebiten.CursorPosition()
+	mouseX, mouseY := g.input.CursorPosition()
 	for i, shipItem := range Items {
 		if mouseX >= shipItem.MenuItem.vector.Min.X && mouseX <= shipItem.MenuItem.vector.Max.X && mouseY >= shipItem.MenuItem.vector.Min.Y && mouseY <= shipItem.MenuItem.vector.Max.Y && Items[i].MenuItem.Active {
 			Items[i].MenuItem.Choosen = false
@@ -143,7 +162,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuUpdate() {
 		if scs.returnButton.Active {
 			scs.returnButton.Choosen = true
 		}
-		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if g.input.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 			err := scs.returnButton.Action(scs.Game)
 			if err != nil {
 				log.Fatal(err)
@@ -152,7 +171,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuUpdate() {
 	}
 
 	// Mouse click on menu items
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+	if g.input.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		for i, shipItem := range Items {
 			if mouseX >= shipItem.MenuItem.vector.Min.X && mouseX <= shipItem.MenuItem.vector.Max.X && mouseY >= shipItem.MenuItem.vector.Min.Y && mouseY <= shipItem.MenuItem.vector.Max.Y && Items[i].MenuItem.Active {
 				_ = Items[i].MenuItem.Action(g)
@@ -162,8 +181,8 @@ func (scs *shipChoosingScreen) shipChoosingMenuUpdate() {
 	}
 
 	// Return to game if started
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
-		g.state = config.InGame
+	if g.input.IsKeyJustPressed(ebiten.KeyEscape) && g.started {
+		g.scenes.Switch(g.play)
 	}
 }
 
@@ -192,7 +211,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuDraw(screen *ebiten.Image) {
 		}
 		chars := len([]rune(i.MenuItem.Label))
 		fontShiftX := (i.MenuItem.vector.Max.X - i.MenuItem.vector.Min.X - chars*charWidth) / 2
-		screen.DrawImage(i.Ship.Sprite, op)
+		screen.DrawImage(i.Ship.Sprite.img, op)
 		text.Draw(screen, fmt.Sprintf("%v", i.MenuItem.Label), text.FaceWithLineHeight(g.Options.ProfileFont, 20*scale), i.MenuItem.vector.Min.X+fontShiftX-2, i.MenuItem.vector.Max.Y+charHeight*3-2, color.RGBA{0, 0, 0, 255})
 		text.Draw(screen, fmt.Sprintf("%v", i.MenuItem.Label), text.FaceWithLineHeight(g.Options.ProfileFont, 20*scale), i.MenuItem.vector.Min.X+fontShiftX, i.MenuItem.vector.Max.Y+charHeight*3, colorr)
 	}
@@ -208,7 +227,7 @@ func (scs *shipChoosingScreen) shipChoosingMenuDraw(screen *ebiten.Image) {
 	text.Draw(screen, fmt.Sprintf("%v", scs.returnButton.Label), scs.Game.Options.ScoreFont, scs.returnButton.vector.Min.X+1, scs.returnButton.vector.Min.Y+charRetButHeight, colorr)
 }
 
-func makeShipMenuItem(ship *Ship) *shipMenuItem {
+func makeShipMenuItem(ship *ShipDef) *shipMenuItem {
 	return &shipMenuItem{
 		Ship: ship,
 		MenuItem: &MenuItem{
@@ -216,17 +235,7 @@ func makeShipMenuItem(ship *Ship) *shipMenuItem {
 			Active:  true,
 			Choosen: false,
 			Action: func(g *Game) error {
-				switch ship.Name {
-				case "Angry ocelot":
-					ship.UniqueWeapon = NewAngryOcelotWeapon(g.player)
-				case "Mighty orca":
-					ship.UniqueWeapon = NewMightyOrcaWeapon(g.player)
-				case "Shady weasel":
-					ship.UniqueWeapon = NewShadyWeaselWeapon(g.player)
-				}
-				g.choosenStartShip = ship
-				g.player.SetShip(ship)
-				g.state = config.InGame
+				g.StartRun(ship)
 				return nil
 			},
 		},
@@ -1,184 +1,179 @@
 package game
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
 	"astrogame/assets"
-	"astrogame/config"
-	"astrogame/objects"
-	"math"
-	"time"
+)
 
-	"github.com/hajimehoshi/ebiten/v2"
+const (
+	shipsPath    = "data/ships.json"
+	shipsVersion = 1
 )
 
+// shipMounts are where the weapons without mounts of their own fire from.
+// Twin weapons use the wings, every other one the nose.
+type shipMounts struct {
+	Nose  *mount  `json:"nose"`
+	Wings []mount `json:"wings"`
+}
+
+var defaultShipMounts = shipMounts{
+	Nose:  &mount{Y: -0.5},
+	Wings: []mount{{X: -0.25, Y: -0.5}, {X: 0.25, Y: -0.5}},
+}
+
+// ShipDef is a ship of the catalog. Runs fly a copy made by newShip, so
+// nothing a run does to its ship reaches the next one.
+type ShipDef struct {
+	Name                        string     `json:"name"`
+	Sprite                      spriteRef  `json:"sprite"`
+	HP                          int        `json:"hp"`
+	Velocity                    float64    `json:"velocity"`
+	HPMod                       float64    `json:"hpMod"`
+	VelocityMod                 float64    `json:"velocityMod"`
+	WeaponFireRateMod           float64    `json:"fireRateMod"`
+	WeaponDamageMod             float64    `json:"damageMod"`
+	WeaponProjectileVelocityMod float64    `json:"projectileVelocityMod"`
+	Mounts                      shipMounts `json:"mounts"`
+	// UniqueWeapon names the weapon definition the ship starts with
+	UniqueWeapon string `json:"uniqueWeapon"`
+	// UnlockLevel is the number of levels a pilot has to clear to fly it
+	UnlockLevel int `json:"unlockLevel"`
+}
+
+// Ship is the ship of the current run.
 type Ship struct {
-	Name                        string
-	Sprite                      *ebiten.Image
-	HP                          int
-	Velocity                    float64
-	HPMod                       float64
-	VelocityMod                 float64
-	WeaponFireRateMod           float64
-	WeaponDamageMod             float64
-	WeaponProjectileVelocityMod float64
-	UniqueWeapon                *Weapon
+	ShipDef
 }
 
-var AngryOcelot = &Ship{
-	Name:                        "Angry ocelot",
-	Sprite:                      assets.ShipAngryOcelotSprite,
-	HP:                          5,
-	Velocity:                    1.0,
-	WeaponFireRateMod:           1.0,
-	WeaponDamageMod:             1.0,
-	WeaponProjectileVelocityMod: 1.0,
-	HPMod:                       1.0,
-	VelocityMod:                 1.0,
+func (d *ShipDef) newShip() *Ship {
+	s := &Ship{ShipDef: *d}
+	if d.Mounts.Nose != nil {
+		nose := *d.Mounts.Nose
+		s.Mounts.Nose = &nose
+	}
+	s.Mounts.Wings = slices.Clone(d.Mounts.Wings)
+	return s
 }
 
-var MightyOrca = &Ship{
-	Name:                        "Mighty orca",
-	Sprite:                      assets.ShipMightyOrcaSprite,
-	HP:                          20,
-	Velocity:                    -4.0,
-	WeaponFireRateMod:           1.2,
-	WeaponDamageMod:             1.0,
-	WeaponProjectileVelocityMod: 1.0,
-	HPMod:                       1.6,
-	VelocityMod:                 0.7,
+// weaponMounts returns the mounts a weapon firing in the pattern uses when
+// it has none of its own.
+func (s *Ship) weaponMounts(pattern string) []mount {
+	if pattern == "twin" {
+		if len(s.Mounts.Wings) == 0 {
+			return defaultShipMounts.Wings
+		}
+		return s.Mounts.Wings
+	}
+	if s.Mounts.Nose == nil {
+		return []mount{*defaultShipMounts.Nose}
+	}
+	return []mount{*s.Mounts.Nose}
 }
 
-var ShadyWeasel = &Ship{
-	Name:                        "Shady weasel",
-	Sprite:                      assets.ShipShadyWeaselSprite,
-	HP:                          0,
-	Velocity:                    4.0,
-	WeaponFireRateMod:           1.2,
-	WeaponDamageMod:             1,
-	WeaponProjectileVelocityMod: 1.5,
-	HPMod:                       0.7,
-	VelocityMod:                 1.5,
+var ships = sync.OnceValue(func() []*ShipDef {
+	var defs []*ShipDef
+	err := assets.LoadData(shipsPath, func(data []byte) error {
+		var err error
+		defs, err = readShipDefs(data)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+	return defs
+})
+
+// Ships lists the catalog in the order the ship choosing screen shows it.
+func Ships() []*ShipDef {
+	return ships()
 }
 
-func NewAngryOcelotWeapon(p *Player) *Weapon {
-	weaponType := &config.WeaponType{
-		Sprite:                        objects.ScaleImg(assets.MissileSprite, 0.8),
-		ItemSprite:                    objects.ScaleImg(assets.ItemMissileSprite, 0.5),
-		IntercectAnimationSpriteSheet: assets.LightMissileBlowSpriteSheet,
-		Velocity:                      600,
-		Damage:                        3,
-		TargetType:                    config.TargetTypeStraight,
-		WeaponName:                    "angryOcelotWeapon",
-		Scale:                         p.game.Options.ResolutionMultipler,
-		StartTime:                     250,
-		StartAmmo:                     120,
-	}
-	weapon := Weapon{
-		projectile: Projectile{
-			wType: weaponType,
-		},
-		UpdateParams: func(player *Player, w *Weapon) {
-		},
-		shootCooldown: config.NewTimer(time.Millisecond * weaponType.StartTime),
-		Shoot: func(p *Player) {
-			bounds := p.sprite.Bounds()
-			halfW := float64(bounds.Dx()) / 2
-			w := p.sprite.Bounds().Dx()
-			h := p.sprite.Bounds().Dy()
-			px, py := p.position.X+float64(w)/2, p.position.Y+float64(h)/2
-			spawnPos := config.Vector{
-				X: px + ((p.position.X+halfW-px)*math.Cos(-p.rotation) - (py-p.position.Y)*math.Sin(-p.rotation)),
-				Y: py - ((p.position.X+halfW-px)*math.Sin(-p.rotation) + (py-p.position.Y)*math.Cos(-p.rotation)),
-			}
-			animation := NewAnimation(config.Vector{}, weaponType.IntercectAnimationSpriteSheet, 1, 56, 60, false, "projectileBlow", 0)
-			projectile := NewProjectile(p.game, spawnPos, p.rotation, weaponType, animation, 0)
-			projectile.owner = config.OwnerPlayer
-			p.game.AddProjectile(projectile)
-			p.curWeapon.ammo--
-		},
-	}
-	return &weapon
+func ShipByName(name string) *ShipDef {
+	for _, s := range Ships() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
 }
 
-func NewMightyOrcaWeapon(p *Player) *Weapon {
-	weaponType := &config.WeaponType{
-		Sprite:                        objects.ScaleImg(assets.MachineGun, p.game.Options.ResolutionMultipler),
-		ItemSprite:                    objects.ScaleImg(assets.ItemMachineGunSprite, p.game.Options.ResolutionMultipler),
-		IntercectAnimationSpriteSheet: assets.ProjectileBlowSpriteSheet,
-		Velocity:                      900,
-		Damage:                        1,
-		TargetType:                    config.TargetTypeStraight,
-		WeaponName:                    "mightyOrcaWeapon",
-		StartTime:                     180,
-		StartAmmo:                     180,
-	}
-	weapon := Weapon{
-		projectile: Projectile{
-			wType: weaponType,
-		},
-		UpdateParams: func(player *Player, w *Weapon) {
-		},
-		shootCooldown: config.NewTimer(time.Millisecond * weaponType.StartTime),
-		Shoot: func(p *Player) {
-			bounds := p.sprite.Bounds()
-			halfW := float64(bounds.Dx()) / 2
-			halfH := float64(bounds.Dy()) / 2
-
-			spawnPos := config.Vector{
-				X: p.position.X + halfW + math.Sin(p.rotation)*bulletSpawnOffset,
-				Y: p.position.Y + halfH + math.Cos(p.rotation)*-bulletSpawnOffset,
-			}
-			animation := NewAnimation(config.Vector{}, weaponType.IntercectAnimationSpriteSheet, 1, 40, 40, false, "projectileBlow", 0)
-			projectile := NewProjectile(p.game, spawnPos, p.rotation, weaponType, animation, 0)
-			projectile.owner = config.OwnerPlayer
-			p.game.AddProjectile(projectile)
-			p.curWeapon.ammo--
-		},
-	}
-	return &weapon
+// readShipDefs decodes and checks the ship catalog, reporting every problem
+// at once.
+func readShipDefs(data []byte) ([]*ShipDef, error) {
+	var file struct {
+		Version int        `json:"version"`
+		Ships   []*ShipDef `json:"ships"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("ships: %w", err)
+	}
+	if file.Version != shipsVersion {
+		return nil, fmt.Errorf("ships: unsupported version %d", file.Version)
+	}
+
+	var errs []error
+	if len(file.Ships) == 0 {
+		errs = append(errs, errors.New("at least one ship is needed"))
+	}
+	for i, d := range file.Ships {
+		what := fmt.Sprintf("ships[%d] %q", i, d.Name)
+		if d.Name == "" || slices.ContainsFunc(file.Ships[:i], func(o *ShipDef) bool { return o.Name == d.Name }) {
+			errs = append(errs, fmt.Errorf("%s: name must be set and unique", what))
+		}
+		errs = append(errs, d.validate(what)...)
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("ships: %w", err)
+	}
+	return file.Ships, nil
 }
 
-func NewShadyWeaselWeapon(p *Player) *Weapon {
-	weaponType := &config.WeaponType{
-		Sprite:                        objects.ScaleImg(assets.PlasmaGun, 0.8*p.game.Options.ResolutionMultipler),
-		ItemSprite:                    objects.ScaleImg(assets.ItemPlasmaGunSprite, p.game.Options.ResolutionMultipler),
-		IntercectAnimationSpriteSheet: assets.ProjectileBlowSpriteSheet,
-		InstantAnimationSpiteSheet:    assets.PlasmaGunProjectileSpriteSheet,
-		Velocity:                      600,
-		AnimationOnly:                 true,
-		Damage:                        3,
-		TargetType:                    config.TargetTypeStraight,
-		WeaponName:                    "shadyWeaselWeapon",
-		StartTime:                     480,
-		StartAmmo:                     80,
-	}
-
-	weapon := Weapon{
-		projectile: Projectile{
-			wType: weaponType,
-		},
-		UpdateParams: func(player *Player, w *Weapon) {
-			// w.projectile.wType.Velocity = (600 + player.params.PlasmaGunVelocityMultiplier)
-			// w.shootCooldown.Restart(time.Millisecond * (w.projectile.wType.StartTime - player.params.PlasmaGunSpeedUpscale))
-		},
-		shootCooldown: config.NewTimer(time.Millisecond * (weaponType.StartTime - p.params.PlasmaGunSpeedUpscale)),
-		Shoot: func(p *Player) {
-			bounds := p.sprite.Bounds()
-			halfW := float64(bounds.Dx()) / 2
-			halfH := float64(bounds.Dy()) / 2
-
-			spawnPos := config.Vector{
-				X: p.position.X + halfW + math.Sin(p.rotation)*bulletSpawnOffset,
-				Y: p.position.Y + halfH + math.Cos(p.rotation)*-bulletSpawnOffset,
-			}
-			plasmaAnimation := NewAnimation(config.Vector{}, weaponType.InstantAnimationSpiteSheet, 1, 55, 50, true, "projectileInstant", 0)
-			animation := NewAnimation(config.Vector{}, weaponType.IntercectAnimationSpriteSheet, 1, 40, 40, false, "projectileBlow", 0)
-			projectile := NewProjectile(p.game, spawnPos, p.rotation, weaponType, animation, 3)
-			projectile.owner = config.OwnerPlayer
-			projectile.instantAnimation = plasmaAnimation
-			p.game.AddProjectile(projectile)
-			p.game.AddAnimation(plasmaAnimation)
-			p.curWeapon.ammo--
-		},
-	}
-	return &weapon
+func (d *ShipDef) validate(what string) []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", what, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	if d.Sprite.Scale == 0 {
+		d.Sprite.Scale = 1
+	}
+	check(d.Sprite.Scale > 0, "sprite: scale must be positive")
+	if d.Sprite.Path == "" {
+		check(false, "sprite: path is missing")
+	} else {
+		img, err := assets.LoadImage(d.Sprite.Path)
+		check(err == nil, "sprite: %v", err)
+		d.Sprite.img = img
+	}
+	check(d.HPMod > 0, "hpMod must be positive, got %v", d.HPMod)
+	check(d.VelocityMod > 0, "velocityMod must be positive, got %v", d.VelocityMod)
+	check(d.WeaponFireRateMod > 0, "fireRateMod must be positive, got %v", d.WeaponFireRateMod)
+	check(d.WeaponDamageMod > 0, "damageMod must be positive, got %v", d.WeaponDamageMod)
+	check(d.WeaponProjectileVelocityMod > 0, "projectileVelocityMod must be positive, got %v", d.WeaponProjectileVelocityMod)
+	check(d.UnlockLevel >= 0, "unlockLevel must not be negative")
+
+	w := weaponDef(d.UniqueWeapon)
+	check(w != nil, "uniqueWeapon: no weapon %q", d.UniqueWeapon)
+	check(w == nil || w.Slot == slotUnique, "uniqueWeapon: %q is not a %s weapon", d.UniqueWeapon, slotUnique)
+
+	if d.Mounts.Nose == nil {
+		d.Mounts.Nose = defaultShipMounts.Nose
+	}
+	if len(d.Mounts.Wings) == 0 {
+		d.Mounts.Wings = defaultShipMounts.Wings
+	}
+	return errs
 }
@@ -0,0 +1,492 @@
+package game
+
+import (
+	"astrogame/config"
+	"slices"
+)
+
+// Snapshot is a summary of the running simulation, handy for asserting on
+// the world state after stepping it.
+type Snapshot struct {
+	Tick             int
+	Seed             int64
+	Score            int
+	HP               int
+	ShieldHP         int
+	Level            int
+	Stage            int
+	Wave             int
+	Meteors          int
+	Enemies          int
+	BossHP           int
+	Projectiles      int
+	EnemyProjectiles int
+	Items            int
+	InGame           bool
+}
+
+// NewSimulation creates a game that starts a run right away with the given
+// ship and seed and reads all of its input from src. It never touches the
+// window, so it can be stepped from tests, bots or replays.
+func NewSimulation(src InputSource, ship *ShipDef, seed int64) *Game {
+	g := newGame(1, src, seed, DefaultSettings())
+	g.StartRun(ship)
+	return g
+}
+
+// Run advances the game by the given number of ticks.
+func (g *Game) Run(ticks int) error {
+	for i := 0; i < ticks; i++ {
+		err := g.Update()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Game) Snapshot() Snapshot {
+	s := Snapshot{
+		Tick:             g.tick,
+		Seed:             g.seed,
+		Score:            g.score,
+		HP:               g.player.params.HP,
+		Level:            g.curLevel.LevelId,
+		Stage:            g.CurStage.StageId,
+		Wave:             g.CurWave.WaveId,
+		Meteors:          g.world.meteors.Count(),
+		Enemies:          g.world.enemies.Count(),
+		Projectiles:      g.world.projectiles.Count(),
+		EnemyProjectiles: g.world.enemyProjectiles.Count(),
+		Items:            g.world.items.Count(),
+		InGame:           g.scenes.Top() == g.play,
+	}
+	if g.player.shield != nil {
+		s.ShieldHP = g.player.shield.HP
+	}
+	if g.boss != nil && g.boss.Alive() {
+		s.BossHP = g.boss.HP()
+	}
+	return s
+}
+
+// Step advances meteors, enemies, projectiles, beams, blows and collisions
+// by one tick using the input of the current tick.
+func (g *Game) Step() {
+	g.runTicks++
+	// Game logic
+	g.player.Update()
+
+	// Meteor spawning
+	g.meteorSpawnTimer.Update()
+	if g.meteorSpawnTimer.IsReady() {
+		g.meteorSpawnTimer.Reset()
+		if g.CurStage.MeteorsCount > 0 {
+			m := NewMeteor(g.baseVelocity, g)
+			g.AddMeteor(m)
+			g.CurStage.MeteorsCount--
+		}
+	}
+
+	// Item spawning
+	g.itemSpawnTimer.Update()
+	if g.itemSpawnTimer.IsReady() {
+		if len(g.CurStage.Items) > 0 {
+			var target config.Vector
+			var startPos config.Vector
+			itemParam := g.CurStage.Items[0]
+			item := NewItem(g, target, startPos, &itemParam)
+			if len(g.CurStage.Items) > 1 {
+				g.itemSpawnTimer.Restart(g.CurStage.Items[1].ItemSpawnTime)
+			}
+			//g.itemSpawnTimer.Restart(g.CurStage.Items[1].ItemSpawnTime)
+			itemWidth := item.sprite().Bounds().Dx()
+			itemHight := item.sprite().Bounds().Dy()
+			posX := g.rng.Intn(int(g.Options.ScreenWidth) - (itemWidth + itemWidth/2))
+			startPos = config.Vector{
+				X: float64(posX + itemWidth),
+				Y: -(float64(itemHight)),
+			}
+			target = config.Vector{
+				X: startPos.X,
+				Y: g.Options.ScreenHeight + 10,
+			}
+			item.SetDirection(target, startPos, &itemParam)
+			item.target = target
+			g.AddItem(item)
+			g.CurStage.Items = slices.Delete(g.CurStage.Items, 0, 1)
+		}
+	}
+
+	// Enemy spawning
+	g.batchesSpawnTimer.Update()
+	if g.batchesSpawnTimer.IsReady() {
+		if len(g.CurWave.Batches) > 0 {
+			batch := g.CurWave.Batches[0]
+			if len(g.CurWave.Batches) > 1 {
+				g.batchesSpawnTimer = config.NewTimer(g.CurWave.Batches[1].BatchSpawnTime)
+			}
+			g.batchesSpawnTimer.Reset()
+			slots := g.formationSlots(batch)
+			newMovement := g.batchMovements(batch.Movement, slots)
+			for i, slot := range slots {
+				startPos := slot.position
+				var target config.Vector
+				e := NewEnemy(g, target, startPos, *batch.Type)
+				e.TargetType = batch.TargetType
+				switch batch.TargetType {
+				case config.OwnerPlayer:
+					target = config.Vector{
+						X: g.player.position.X,
+						Y: g.player.position.Y,
+					}
+				case "straight":
+					target = config.Vector{
+						X: startPos.X,
+						Y: g.Options.ScreenHeight + 10,
+					}
+				}
+				e.SetDirection(target, startPos, *batch.Type)
+				e.target = target
+				e.move = newMovement(e, i)
+				g.AddEnemy(e)
+			}
+			g.CurWave.Batches = slices.Delete(g.CurWave.Batches, 0, 1)
+		}
+	}
+
+	if len(g.CurWave.Batches) == 0 {
+		if g.CurWave.WaveId < len(g.CurStage.Waves)-1 {
+			g.CurWave = &g.CurStage.Waves[g.CurWave.WaveId+1]
+			g.publishWaveStarted()
+		} else if g.CurStage.StageId < len(g.curLevel.Stages)-1 {
+			if g.CurStage.MeteorsCount == 0 && len(g.CurStage.Items) == 0 {
+				g.events.Publish(StageCleared{Level: g.curLevel.LevelId, Stage: g.CurStage.StageId})
+				g.CurStage = &g.curLevel.Stages[g.CurStage.StageId+1]
+				g.CurWave = &g.CurStage.Waves[0]
+				g.beginStage()
+				g.publishWaveStarted()
+			}
+		} else if g.curLevel.Boss != nil && !g.bossDefeated {
+			// The boss comes once the last stage is cleared of enemies
+			if g.boss == nil && g.world.enemies.Count() == 0 && g.CurStage.MeteorsCount == 0 {
+				g.spawnBoss()
+			}
+		} else {
+			g.events.Publish(LevelCleared{Level: g.curLevel.LevelId})
+			if g.curLevel.LevelId < len(g.levels)-1 {
+				g.curLevel = g.levels[g.curLevel.LevelId+1]
+				g.CurStage = &g.curLevel.Stages[0]
+				g.CurWave = &g.CurStage.Waves[0]
+				g.beginStage()
+				g.publishWaveStarted()
+			} else {
+				g.events.Publish(RunCompleted{})
+				g.Reset()
+			}
+		}
+	}
+
+	for _, m := range g.world.meteors.All() {
+		m.Update()
+		if m.Collider().Min.Y >= int(g.Options.ScreenHeight) {
+			g.world.Despawn(m)
+		}
+	}
+
+	for _, p := range g.world.projectiles.All() {
+		if !p.Alive() {
+			continue
+		}
+		p.Update()
+		if p.position.Y < 0 || p.position.Y >= g.Options.ScreenHeight+float64(p.sprite.Bounds().Dy()) {
+			p.Destroy(g)
+		} else if p.position.X < 0 || p.position.X > g.Options.ScreenWidth+float64(p.sprite.Bounds().Dx()) {
+			p.Destroy(g)
+		}
+	}
+
+	for _, p := range g.world.enemyProjectiles.All() {
+		if p.wType.TargetType == config.TargetTypePlayer && p.owner == config.OwnerEnemy {
+			p.target = config.Vector{
+				X: g.player.position.X,
+				Y: g.player.position.Y,
+			}
+		}
+		p.Update()
+		if p.position.Y >= g.Options.ScreenHeight {
+			p.Destroy(g)
+		}
+	}
+
+	for _, item := range g.world.items.All() {
+		item.Update()
+		if item.position.Y >= g.Options.ScreenHeight {
+			g.world.Despawn(item)
+		}
+	}
+
+	// Collisions only look at projectiles sharing a grid cell with the target
+	projectiles := g.world.projectiles.All()
+	enemyProjectiles := g.world.enemyProjectiles.All()
+	indexProjectiles(g.projectileHash, projectiles)
+	indexProjectiles(g.enemyProjHash, enemyProjectiles)
+
+	// Check for meteor/projectile collisions
+	// Check for meteor/enemy projectile collisions
+	for _, m := range g.world.meteors.All() {
+		g.candidates = g.projectileHash.Query(m.Collider(), g.candidates)
+		for _, j := range g.candidates {
+			b := projectiles[j]
+			if m.Alive() && b.Alive() && config.IntersectRect(m.Collider(), b.Collider()) {
+				g.world.Despawn(m)
+				g.IntersectProjectile(b)
+				g.events.Publish(MeteorDestroyed{Meteor: m})
+			}
+		}
+
+		g.candidates = g.enemyProjHash.Query(m.Collider(), g.candidates)
+		for _, j := range g.candidates {
+			b := enemyProjectiles[j]
+			if m.Alive() && config.IntersectRect(m.Collider(), b.Collider()) {
+				g.IntersectProjectile(b)
+			}
+		}
+	}
+
+	// Check for enemy/projectile collisions
+	// Check for enemy/player collisions
+	// Check for enemy/beam collisions
+	// Check for enemy/blow collisions
+	enemies := g.world.enemies.All()
+	for _, m := range enemies {
+		if m.TargetType == config.TargetTypePlayer {
+			m.target = config.Vector{
+				X: g.player.position.X,
+				Y: g.player.position.Y,
+			}
+		}
+		m.Update()
+		if m.position.Y >= g.Options.ScreenHeight+float64(m.Collider().Dy()) {
+			g.world.Despawn(m)
+		}
+
+		g.candidates = g.projectileHash.Query(m.Collider(), g.candidates)
+		for _, j := range g.candidates {
+			b := projectiles[j]
+			if m.Alive() && b.Alive() && config.IntersectRect(m.Collider(), b.Collider()) && b.owner == config.OwnerPlayer {
+				if b.wType.BlastRadius > 0 {
+					bounds := b.sprite.Bounds()
+					blow := NewBlow(b.position.X+float64(bounds.Dx()/2), b.position.Y+float64(bounds.Dy()/2), float64(bounds.Dx())*b.wType.BlastRadius, b.wType.Damage)
+					blow.Steps = 5
+					g.AddBlow(blow, m.position)
+				} else {
+					m.HP -= b.wType.Damage
+					if m.HP <= 0 {
+						g.KillEnemy(m)
+					}
+				}
+				g.IntersectProjectile(b)
+			}
+		}
+
+		g.world.blows.Each(func(blow *Blow) {
+			if m.Alive() && config.IntersectCircle(m.Collider(), blow.circle) {
+				m.HP -= blow.Damage
+				if m.HP <= 0 {
+					g.KillEnemy(m)
+				}
+			}
+		})
+
+		if m.Alive() && config.IntersectRect(m.Collider(), g.player.Collider()) {
+			if g.player.shield != nil {
+				g.player.shield = nil
+				g.events.Publish(ShieldBroken{})
+			} else {
+				g.events.Publish(PlayerDied{})
+				g.Reset()
+				break
+			}
+		}
+
+		g.world.beams.Each(func(beam *Beam) {
+			if m.Alive() && config.IntersectLine(beam.Line, m.Collider()) {
+				m.HP -= beam.Damage
+				if m.HP <= 0 {
+					g.KillEnemy(m)
+				}
+			}
+		})
+	}
+
+	// Check for boss/projectile collisions
+	// Check for boss/player collisions
+	// Check for boss/beam collisions
+	// Check for boss/blow collisions
+	for _, b := range g.world.bosses.All() {
+		if !b.Alive() {
+			continue
+		}
+		b.Update()
+		if g.collideBoss(b, projectiles) {
+			break
+		}
+	}
+	if g.ResolutionChange {
+		g.ResolutionChange = false
+	}
+	// Check for enemy projectile/player projectile collisions
+	for _, m := range enemyProjectiles {
+		g.candidates = g.projectileHash.Query(m.Collider(), g.candidates)
+		for _, j := range g.candidates {
+			b := projectiles[j]
+			if m.Alive() && b.Alive() && config.IntersectRect(m.Collider(), b.Collider()) {
+				g.IntersectProjectile(m)
+				g.IntersectProjectile(b)
+			}
+		}
+		g.world.beams.Each(func(beam *Beam) {
+			if config.IntersectLine(beam.Line, m.Collider()) {
+				g.IntersectProjectile(m)
+			}
+		})
+	}
+
+	// Check for projectiles/player collisions
+	for _, p := range g.world.enemyProjectiles.All() {
+		if p.Alive() && config.IntersectRect(p.Collider(), g.player.Collider()) {
+			if g.player.shield != nil {
+				g.player.shield.HP -= p.wType.Damage
+				g.events.Publish(PlayerDamaged{Damage: p.wType.Damage, Shield: true})
+				if g.player.shield.HP <= 0 {
+					g.player.shield = nil
+					g.events.Publish(ShieldBroken{})
+				}
+			} else {
+				g.player.params.HP -= p.wType.Damage
+				g.events.Publish(PlayerDamaged{Damage: p.wType.Damage})
+			}
+			g.IntersectProjectile(p)
+			if g.player.params.HP <= 0 {
+				g.events.Publish(PlayerDied{})
+				g.Reset()
+				break
+			}
+		}
+	}
+
+	// Check for meteor/player collisions
+	for _, m := range g.world.meteors.All() {
+		if m.Alive() && config.IntersectRect(m.Collider(), g.player.Collider()) {
+			g.events.Publish(PlayerDied{})
+			g.Reset()
+			break
+		}
+	}
+
+	// Check for item/player collisions
+	g.world.items.Each(func(item *Item) {
+		if config.IntersectRect(item.Collider(), g.player.Collider()) {
+			if g.world.Despawn(item) {
+				g.events.Publish(ItemPicked{Item: item})
+			}
+		}
+	})
+
+	g.world.beamAnimations.Each(func(ba *BeamAnimation) {
+		ba.Update()
+		if ba.Step >= ba.Steps {
+			g.world.Despawn(ba)
+		}
+	})
+
+	g.world.animations.Each(func(a *Animation) {
+		a.Update()
+		if a.currF >= a.numFrames && !a.looping {
+			g.world.Despawn(a)
+		}
+		if a.name == "shield" && g.player.shield == nil {
+			g.world.Despawn(a)
+		}
+	})
+
+	// Remove blows
+	g.world.blows.Each(func(b *Blow) {
+		b.Update()
+		if b.Step >= b.Steps {
+			g.world.Despawn(b)
+		}
+	})
+
+	// Remove beams
+	g.world.beams.Each(func(b *Beam) {
+		b.Update()
+		if b.Step >= b.Steps {
+			g.world.Despawn(b)
+		}
+	})
+
+	g.world.Flush()
+}
+
+// collideBoss hits the parts of the boss with the player's fire and reports
+// whether the boss rammed the player to death.
+func (g *Game) collideBoss(b *Boss, projectiles []*Projectile) bool {
+	for i := range b.parts {
+		if b.parts[i].dead {
+			continue
+		}
+		collider := b.partCollider(i)
+		g.candidates = g.projectileHash.Query(collider, g.candidates)
+		for _, j := range g.candidates {
+			p := projectiles[j]
+			if b.Alive() && p.Alive() && config.IntersectRect(collider, p.Collider()) && p.owner == config.OwnerPlayer {
+				if p.wType.BlastRadius > 0 {
+					bounds := p.sprite.Bounds()
+					blow := NewBlow(p.position.X+float64(bounds.Dx()/2), p.position.Y+float64(bounds.Dy()/2), float64(bounds.Dx())*p.wType.BlastRadius, p.wType.Damage)
+					blow.Steps = 5
+					g.AddBlow(blow, p.position)
+				} else {
+					b.hit(i, p.wType.Damage)
+				}
+				g.IntersectProjectile(p)
+			}
+		}
+
+		g.world.blows.Each(func(blow *Blow) {
+			if config.IntersectCircle(collider, blow.circle) {
+				b.hit(i, blow.Damage)
+			}
+		})
+
+		g.world.beams.Each(func(beam *Beam) {
+			if config.IntersectLine(beam.Line, collider) {
+				b.hit(i, beam.Damage)
+			}
+		})
+
+		if b.Alive() && config.IntersectRect(collider, g.player.Collider()) {
+			if g.player.shield != nil {
+				g.player.shield = nil
+				g.events.Publish(ShieldBroken{})
+			} else {
+				g.events.Publish(PlayerDied{})
+				g.Reset()
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func indexProjectiles(h *config.SpatialHash, projectiles []*Projectile) {
+	h.Clear()
+	for i, p := range projectiles {
+		h.Insert(i, p.Collider())
+	}
+}
+
+func (g *Game) publishWaveStarted() {
+	g.events.Publish(WaveStarted{Level: g.curLevel.LevelId, Stage: g.CurStage.StageId, Wave: g.CurWave.WaveId})
+}
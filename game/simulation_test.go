@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const simulationTicks = 1200
+
+// scriptedInput keeps firing while sweeping the ship from side to side.
+func scriptedInput(tick int) InputState {
+	keys := []ebiten.Key{ebiten.KeySpace}
+	if (tick/120)%2 == 0 {
+		keys = append(keys, ebiten.KeyA)
+	} else {
+		keys = append(keys, ebiten.KeyD)
+	}
+	return InputState{Keys: keys}
+}
+
+func runSimulation(t *testing.T, seed int64) Snapshot {
+	t.Helper()
+	ships := Ships()
+	if len(ships) == 0 {
+		t.Fatal("no ships in the catalog")
+	}
+	g := NewSimulation(InputFunc(scriptedInput), ships[0], seed)
+	err := g.Run(simulationTicks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.Snapshot()
+}
+
+func TestSimulation(t *testing.T) {
+	s := runSimulation(t, 42)
+	if s.Tick != simulationTicks {
+		t.Errorf("tick = %d, want %d", s.Tick, simulationTicks)
+	}
+	if s.Seed != 42 {
+		t.Errorf("seed = %d, want 42", s.Seed)
+	}
+	if s.Projectiles == 0 {
+		t.Error("holding fire left no projectiles in flight")
+	}
+	if s.Enemies+s.Meteors == 0 && s.Score == 0 {
+		t.Error("nothing spawned in the first level")
+	}
+
+	again := runSimulation(t, 42)
+	if again != s {
+		t.Errorf("same seed and input gave %+v, then %+v", s, again)
+	}
+}
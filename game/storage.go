@@ -0,0 +1,25 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+
+	"astrogame/assets"
+)
+
+const appDirName = assets.AppDirName
+
+// dataPath returns a path inside the per-user directory of the game and makes
+// sure that its parent directory exists.
+func dataPath(elem ...string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(append([]string{dir, appDirName}, elem...)...)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
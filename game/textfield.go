@@ -0,0 +1,40 @@
+package game
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// textField collects letters, digits and spaces typed on the game input, so
+// typing works the same for live input and replays.
+type textField struct {
+	text []rune
+	max  int
+}
+
+func (f *textField) Set(s string) {
+	f.text = []rune(s)
+	if len(f.text) > f.max {
+		f.text = f.text[:f.max]
+	}
+}
+
+func (f *textField) String() string {
+	return string(f.text)
+}
+
+func (f *textField) Update(in *Input) {
+	for k := ebiten.KeyA; k <= ebiten.KeyZ; k++ {
+		if in.IsKeyJustPressed(k) && len(f.text) < f.max {
+			f.text = append(f.text, 'A'+rune(k-ebiten.KeyA))
+		}
+	}
+	for k := ebiten.KeyDigit0; k <= ebiten.KeyDigit9; k++ {
+		if in.IsKeyJustPressed(k) && len(f.text) < f.max {
+			f.text = append(f.text, '0'+rune(k-ebiten.KeyDigit0))
+		}
+	}
+	if in.IsKeyJustPressed(ebiten.KeySpace) && len(f.text) > 0 && len(f.text) < f.max {
+		f.text = append(f.text, ' ')
+	}
+	if in.IsKeyJustPressed(ebiten.KeyBackspace) && len(f.text) > 0 {
+		f.text = f.text[:len(f.text)-1]
+	}
+}
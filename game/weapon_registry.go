@@ -0,0 +1,376 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"astrogame/assets"
+	"astrogame/config"
+)
+
+const (
+	weaponsPath    = "data/weapons.json"
+	weaponsVersion = 2
+
+	slotPrimary   = "primary"
+	slotSecondary = "secondary"
+	slotUnique    = "unique"
+
+	statFireRate = "fireRate"
+	statVelocity = "velocity"
+)
+
+// spriteRef names an image of a weapon. Scale follows the resolution unless
+// the sprite is fixed.
+type spriteRef struct {
+	Path  string  `json:"path"`
+	Scale float64 `json:"scale"`
+	Fixed bool    `json:"fixed"`
+
+	img *ebiten.Image
+}
+
+// mount is where a weapon fires from. X and Y are fractions of the ship
+// sprite from its center, Forward is in pixels along the heading.
+type mount struct {
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Forward float64 `json:"forward"`
+}
+
+type firePatternDef struct {
+	Type string `json:"type"`
+	// Count is the number of shots for spread, radial and beamFan
+	Count int `json:"count"`
+	// Angle is the angle in degrees between two shots of a spread or fan
+	Angle float64 `json:"angle"`
+}
+
+// weaponUpgrade is bought in the profile screen. Step is in milliseconds off
+// the cooldown for fireRate and in pixels per second for velocity.
+type weaponUpgrade struct {
+	Stat  string  `json:"stat"`
+	Label string  `json:"label"`
+	Cost  int     `json:"cost"`
+	Step  float64 `json:"step"`
+
+	id string
+}
+
+// WeaponDef describes a player weapon.
+type WeaponDef struct {
+	Name   string     `json:"name"`
+	Slot   string     `json:"slot"`
+	Sprite spriteRef  `json:"sprite"`
+	Item   *spriteRef `json:"item"`
+	Icon   *spriteRef `json:"icon"`
+	// Blow and Trail name clips of the spritesheet manifest
+	Blow     string          `json:"blow"`
+	Trail    string          `json:"trail"`
+	Damage   int             `json:"damage"`
+	HP       int             `json:"hp"`
+	Velocity float64         `json:"velocity"`
+	Cooldown config.Duration `json:"cooldown"`
+	Ammo     int             `json:"ammo"`
+	// AmmoPerShot defaults to one
+	AmmoPerShot int `json:"ammoPerShot"`
+	// BlastRadius makes a hit blow up in a circle this many projectile
+	// widths wide
+	BlastRadius float64 `json:"blastRadius"`
+	// ShipMods applies the fire rate, damage and velocity modifiers of the
+	// ship
+	ShipMods bool            `json:"shipMods"`
+	Beam     bool            `json:"beam"`
+	Pattern  firePatternDef  `json:"pattern"`
+	Mounts   []mount         `json:"mounts"`
+	Upgrades []weaponUpgrade `json:"upgrades"`
+}
+
+func (d *WeaponDef) upgrade(stat string) *weaponUpgrade {
+	for i := range d.Upgrades {
+		if d.Upgrades[i].Stat == stat {
+			return &d.Upgrades[i]
+		}
+	}
+	return nil
+}
+
+// firePattern shoots one volley of the weapon.
+type firePattern func(w *Weapon, p *Player)
+
+var firePatterns = map[string]firePattern{}
+
+// RegisterFirePattern makes a Go behaviour available to weapon definitions
+// for what data alone can't describe. It has to be called before the first
+// weapon is built, from an init function for example.
+func RegisterFirePattern(name string, fire firePattern) {
+	firePatterns[name] = fire
+}
+
+func init() {
+	RegisterFirePattern("single", fireMounts)
+	RegisterFirePattern("twin", fireMounts)
+	RegisterFirePattern("spread", fireSpread)
+	RegisterFirePattern("beamFan", fireSpread)
+	RegisterFirePattern("radial", fireRadial)
+}
+
+type weaponRegistry struct {
+	defs  []*WeaponDef
+	names map[string]*WeaponDef
+}
+
+var weapons = sync.OnceValue(func() *weaponRegistry {
+	var r *weaponRegistry
+	err := assets.LoadData(weaponsPath, func(data []byte) error {
+		var err error
+		r, err = readWeaponDefs(data)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+	return r
+})
+
+func weaponDef(name string) *WeaponDef {
+	return weapons().names[name]
+}
+
+// readWeaponDefs decodes and checks weapon definitions, reporting every
+// problem at once.
+func readWeaponDefs(data []byte) (*weaponRegistry, error) {
+	var file struct {
+		Version int          `json:"version"`
+		Weapons []*WeaponDef `json:"weapons"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("weapons: %w", err)
+	}
+	if file.Version != weaponsVersion {
+		return nil, fmt.Errorf("weapons: unsupported version %d", file.Version)
+	}
+
+	r := &weaponRegistry{names: map[string]*WeaponDef{}}
+	var errs []error
+	for i, d := range file.Weapons {
+		what := fmt.Sprintf("weapons[%d] %q", i, d.Name)
+		if d.Name == "" || r.names[d.Name] != nil {
+			errs = append(errs, fmt.Errorf("%s: name must be set and unique", what))
+		}
+		errs = append(errs, d.validate(what)...)
+		r.defs = append(r.defs, d)
+		r.names[d.Name] = d
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("weapons: %w", err)
+	}
+	return r, nil
+}
+
+func (d *WeaponDef) validate(what string) []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", what, fmt.Sprintf(format, args...)))
+		}
+	}
+	load := func(field, path string) *ebiten.Image {
+		if path == "" {
+			check(false, "%s: path is missing", field)
+			return nil
+		}
+		img, err := assets.LoadImage(path)
+		check(err == nil, "%s: %v", field, err)
+		return img
+	}
+	sprite := func(field string, s *spriteRef) {
+		if s == nil {
+			return
+		}
+		if s.Scale == 0 {
+			s.Scale = 1
+		}
+		check(s.Scale > 0, "%s: scale must be positive", field)
+		s.img = load(field, s.Path)
+	}
+	clip := func(field, name string) {
+		check(name == "" || assets.FindClip(name) != nil, "%s: no clip %q in the spritesheet manifest", field, name)
+	}
+
+	check(d.Slot == slotPrimary || d.Slot == slotSecondary || d.Slot == slotUnique, "slot must be %q, %q or %q, got %q", slotPrimary, slotSecondary, slotUnique, d.Slot)
+	sprite("sprite", &d.Sprite)
+	sprite("item", d.Item)
+	sprite("icon", d.Icon)
+	clip("blow", d.Blow)
+	clip("trail", d.Trail)
+	check(d.Damage > 0, "damage must be positive, got %d", d.Damage)
+	check(d.HP >= 0, "hp must not be negative")
+	check(d.Beam || d.Pattern.Type == "beamFan" || d.Velocity > 0, "velocity must be positive, got %v", d.Velocity)
+	check(d.Cooldown > 0, "cooldown must be positive")
+	check(d.Ammo > 0, "ammo must be positive, got %d", d.Ammo)
+	if d.AmmoPerShot == 0 {
+		d.AmmoPerShot = 1
+	}
+	check(d.AmmoPerShot > 0, "ammoPerShot must be positive")
+	check(d.BlastRadius >= 0, "blastRadius must not be negative")
+
+	_, ok := firePatterns[d.Pattern.Type]
+	check(ok, "pattern: unknown type %q", d.Pattern.Type)
+	switch d.Pattern.Type {
+	case "spread", "beamFan", "radial":
+		check(d.Pattern.Count > 0, "pattern: count must be positive")
+	}
+
+	for i := range d.Upgrades {
+		u := &d.Upgrades[i]
+		check(u.Stat == statFireRate || u.Stat == statVelocity, "upgrades[%d]: stat must be %q or %q, got %q", i, statFireRate, statVelocity, u.Stat)
+		check(u.Cost > 0, "upgrades[%d]: cost must be positive", i)
+		check(u.Step > 0, "upgrades[%d]: step must be positive", i)
+		check(u.Label != "", "upgrades[%d]: label is missing", i)
+		check(d.upgrade(u.Stat) == u, "upgrades[%d]: %s is upgraded twice", i, u.Stat)
+		u.id = d.Name + "." + u.Stat
+	}
+	return errs
+}
+
+// scale is the scale of the sprite when drawn at the given option scale,
+// sprites marked fixed don't follow the resolution.
+func (s *spriteRef) scale(g *Game, optionScale float64) float64 {
+	if s.Fixed {
+		optionScale /= g.Options.ResolutionMultipler
+	}
+	return optionScale * s.Scale
+}
+
+// newWeaponType builds the projectile type a weapon fires. It keeps the
+// source sprites, they are scaled when drawn.
+func (d *WeaponDef) newWeaponType(g *Game) *config.WeaponType {
+	var item *ebiten.Image
+	if d.Item != nil {
+		item = d.Item.img
+	}
+	wType := &config.WeaponType{
+		Sprite:        d.Sprite.img,
+		ItemSprite:    item,
+		AnimationOnly: d.Trail != "",
+		Damage:        d.Damage,
+		TargetType:    config.TargetTypeStraight,
+		WeaponName:    d.Name,
+		StartTime:     time.Duration(d.Cooldown),
+		StartAmmo:     d.Ammo,
+		BlastRadius:   d.BlastRadius,
+		BlowClip:      d.Blow,
+		TrailClip:     d.Trail,
+	}
+	return wType
+}
+
+// weaponSprite scales the projectile sprite of a weapon type for the given
+// option scale. Enemy weapons aren't in the registry and only get that.
+func (g *Game) weaponSprite(wType *config.WeaponType, optionScale float64) *ebiten.Image {
+	scale := optionScale
+	if d := weaponDef(wType.WeaponName); d != nil {
+		scale = d.Sprite.scale(g, optionScale)
+	}
+	return g.sprites.Scaled(wType.Sprite, scale)
+}
+
+// weaponIcon is the item sprite of a weapon type scaled for the given option
+// scale, or its projectile sprite if it has none.
+func (g *Game) weaponIcon(wType *config.WeaponType, optionScale float64) *ebiten.Image {
+	d := weaponDef(wType.WeaponName)
+	if wType.ItemSprite == nil || d == nil || d.Item == nil {
+		return g.weaponSprite(wType, optionScale)
+	}
+	return g.sprites.Scaled(wType.ItemSprite, d.Item.scale(g, optionScale))
+}
+
+// icon is the picture of the weapon in the profile screen.
+func (d *WeaponDef) icon() *ebiten.Image {
+	if d.Icon != nil {
+		return d.Icon.img
+	}
+	return d.Sprite.img
+}
+
+// mountPositions returns where the mounts of the weapon are on the player
+// right now. Weapons without mounts use the ones of the ship.
+func (w *Weapon) mountPositions(p *Player) []config.Vector {
+	mounts := w.def.Mounts
+	if len(mounts) == 0 {
+		mounts = p.params.Ship.weaponMounts(w.def.Pattern.Type)
+	}
+	bounds := p.sprite.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+	cx, cy := p.position.X+width/2, p.position.Y+height/2
+	sin, cos := math.Sincos(p.rotation)
+	var positions []config.Vector
+	for _, m := range mounts {
+		dx, dy := m.X*width, m.Y*height-m.Forward
+		positions = append(positions, config.Vector{
+			X: cx + dx*cos - dy*sin,
+			Y: cy + dx*sin + dy*cos,
+		})
+	}
+	return positions
+}
+
+func (w *Weapon) fire(p *Player, pos config.Vector, rotation float64) {
+	wType := w.projectile.wType
+	if w.def.Beam || w.def.Pattern.Type == "beamFan" {
+		x, y := p.game.input.CursorPosition()
+		beam := NewBeam(config.Vector{X: float64(x), Y: float64(y)}, rotation, pos, wType, p.game)
+		beam.owner = config.OwnerPlayer
+		p.game.AddBeam(beam)
+		p.game.AddBeamAnimation(beam.NewBeamAnimation())
+		return
+	}
+	projectile := NewProjectile(p.game, pos, rotation, wType, w.def.HP)
+	projectile.owner = config.OwnerPlayer
+	if wType.TrailClip != "" {
+		// The projectile keeps its trail, so it can't come from the pool
+		projectile.instantAnimation = NewAnimation(config.Vector{}, wType.TrailClip, 0)
+		p.game.AddAnimation(projectile.instantAnimation)
+	}
+	p.game.AddProjectile(projectile)
+}
+
+// fireMounts shoots once from every mount.
+func fireMounts(w *Weapon, p *Player) {
+	for _, pos := range w.mountPositions(p) {
+		w.fire(p, pos, p.rotation)
+	}
+}
+
+// fireSpread fans the shots of every mount around the heading.
+func fireSpread(w *Weapon, p *Player) {
+	n := w.def.Pattern.Count
+	step := w.def.Pattern.Angle * math.Pi / 180
+	for _, pos := range w.mountPositions(p) {
+		for i := 0; i < n; i++ {
+			w.fire(p, pos, p.rotation+step*(float64(i)-float64(n-1)/2))
+		}
+	}
+}
+
+// fireRadial shoots evenly in every direction.
+func fireRadial(w *Weapon, p *Player) {
+	n := w.def.Pattern.Count
+	for _, pos := range w.mountPositions(p) {
+		for i := 0; i < n; i++ {
+			w.fire(p, pos, p.rotation+2*math.Pi*float64(i)/float64(n))
+		}
+	}
+}
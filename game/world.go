@@ -0,0 +1,164 @@
+package game
+
+import (
+	"cmp"
+	"slices"
+)
+
+// EntityID identifies an entity for its whole lifetime, ids are never reused
+// within one game.
+type EntityID uint64
+
+// entity is embedded by everything that lives in the world.
+type entity struct {
+	id   EntityID
+	dead bool
+}
+
+func (e *entity) ID() EntityID {
+	return e.id
+}
+
+// Alive reports whether the entity is in the world and was not despawned
+// during the current tick.
+func (e *entity) Alive() bool {
+	return e.id != 0 && !e.dead
+}
+
+func (e *entity) base() *entity {
+	return e
+}
+
+type Entity interface {
+	ID() EntityID
+	Alive() bool
+	base() *entity
+}
+
+// Store keeps the entities of one kind in spawn order.
+type Store[T Entity] struct {
+	entities []T
+}
+
+// All returns the entities of the store. Entities despawned during the current
+// tick stay in it until the end of the tick, so check Alive while iterating.
+func (s *Store[T]) All() []T {
+	return s.entities
+}
+
+// Each calls fn for every alive entity.
+func (s *Store[T]) Each(fn func(T)) {
+	for _, e := range s.entities {
+		if e.Alive() {
+			fn(e)
+		}
+	}
+}
+
+func (s *Store[T]) Get(id EntityID) (T, bool) {
+	i, ok := slices.BinarySearchFunc(s.entities, id, func(e T, id EntityID) int {
+		return cmp.Compare(e.ID(), id)
+	})
+	if !ok || !s.entities[i].Alive() {
+		var zero T
+		return zero, false
+	}
+	return s.entities[i], true
+}
+
+func (s *Store[T]) Count() int {
+	count := 0
+	for _, e := range s.entities {
+		if e.Alive() {
+			count++
+		}
+	}
+	return count
+}
+
+// flush drops despawned entities and hands them to release, if given.
+func (s *Store[T]) flush(release func(T)) {
+	s.entities = slices.DeleteFunc(s.entities, func(e T) bool {
+		if e.Alive() {
+			return false
+		}
+		if release != nil {
+			release(e)
+		}
+		return true
+	})
+}
+
+func (s *Store[T]) clear() {
+	for _, e := range s.entities {
+		e.base().dead = true
+	}
+	s.entities = nil
+}
+
+// World owns every entity of a running game. Spawned entities take part in
+// the current tick right away, despawned ones are removed at its end.
+type World struct {
+	nextID           EntityID
+	meteors          Store[*Meteor]
+	projectiles      Store[*Projectile]
+	enemyProjectiles Store[*Projectile]
+	enemies          Store[*Enemy]
+	bosses           Store[*Boss]
+	items            Store[*Item]
+	blows            Store[*Blow]
+	beams            Store[*Beam]
+	enemyBeams       Store[*Beam]
+	beamAnimations   Store[*BeamAnimation]
+	animations       Store[*Animation]
+}
+
+func spawn[T Entity](w *World, s *Store[T], e T) T {
+	w.nextID++
+	b := e.base()
+	b.id = w.nextID
+	b.dead = false
+	s.entities = append(s.entities, e)
+	return e
+}
+
+// Despawn marks the entity for removal at the end of the tick. It reports
+// false if the entity was already gone, so that effects of a removal happen
+// only once.
+func (w *World) Despawn(e Entity) bool {
+	if !e.Alive() {
+		return false
+	}
+	e.base().dead = true
+	return true
+}
+
+// Flush removes all despawned entities.
+func (w *World) Flush() {
+	w.meteors.flush(nil)
+	w.projectiles.flush(releaseProjectile)
+	w.enemyProjectiles.flush(releaseProjectile)
+	w.enemies.flush(nil)
+	w.bosses.flush(nil)
+	w.items.flush(nil)
+	w.blows.flush(nil)
+	w.beams.flush(nil)
+	w.enemyBeams.flush(nil)
+	w.beamAnimations.flush(nil)
+	w.animations.flush(releaseAnimation)
+}
+
+// Clear despawns every entity at once. Ids keep counting up.
+func (w *World) Clear() {
+	w.meteors.clear()
+	w.projectiles.clear()
+	w.enemyProjectiles.clear()
+	w.enemies.clear()
+	w.bosses.clear()
+	w.items.clear()
+	w.blows.clear()
+	w.beams.clear()
+	w.enemyBeams.clear()
+	w.beamAnimations.clear()
+	w.animations.clear()
+}
@@ -1,13 +1,69 @@
 package main
 
 import (
+	"astrogame/assets"
+	"astrogame/config"
 	"astrogame/game"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
-	g := game.NewGame()
+	seed := flag.Int64("seed", 0, "seed for level generation and spawning, 0 picks a random one")
+	replay := flag.String("replay", "", "play back a recorded replay file")
+	enemies := flag.String("enemies", "", "load the enemy catalog from this JSON file instead of the built-in one")
+	bosses := flag.String("bosses", "", "load the boss catalog from this JSON file instead of the built-in one")
+	campaign := flag.String("campaign", "", "take hand-authored levels from this campaign file, level files are looked up next to it")
+	flag.Parse()
+	for _, err := range assets.Errors() {
+		log.Println(err)
+	}
+	if *enemies != "" {
+		data, err := os.ReadFile(*enemies)
+		if err != nil {
+			log.Fatal(err)
+		}
+		catalog, err := config.ReadEnemyCatalog(data)
+		if err != nil {
+			log.Fatalf("%s: %v", *enemies, err)
+		}
+		config.SetEnemyCatalog(catalog)
+	}
+	if *bosses != "" {
+		data, err := os.ReadFile(*bosses)
+		if err != nil {
+			log.Fatal(err)
+		}
+		catalog, err := config.ReadBossCatalog(data)
+		if err != nil {
+			log.Fatalf("%s: %v", *bosses, err)
+		}
+		config.SetBossCatalog(catalog)
+	}
+	if *campaign != "" {
+		c, err := game.ReadCampaign(os.DirFS(filepath.Dir(*campaign)), filepath.Base(*campaign))
+		if err != nil {
+			log.Fatal(err)
+		}
+		game.SetCampaign(c)
+	}
+	pilots := game.LoadPilots()
+	settings := game.LoadSettings(pilots.CurrentPilot())
+
+	var g *game.Game
+	if *replay != "" {
+		var err error
+		g, err = game.NewReplayGame(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		g = game.NewGame(*seed, pilots, settings)
+	}
 	// fw, _ := ebiten.ScreenSizeInFullscreen()
 	if g.Options.Fullscreen {
 		ebiten.SetFullscreen(true)
@@ -20,6 +76,7 @@ func main() {
 	ebiten.SetWindowSize(int(g.Options.ScreenWidth), int(g.Options.ScreenHeight))
 	ebiten.SetWindowTitle("Astro Ship (Ebitengine Demo)")
 	err := ebiten.RunGame(g)
+	g.Shutdown()
 	if err != nil {
 		panic(err)
 	}
@@ -17,13 +17,15 @@ func RotateAndTranslateObject(angle float64, object *ebiten.Image, screen *ebite
 	screen.DrawImage(object, op)
 }
 
-func RotateAndTranslateAnimation(angle float64, object *ebiten.Image, screen *ebiten.Image, x, y float64) {
-	// w := object.Bounds().Dx()
-	// h := object.Bounds().Dy()
+// RotateAndTranslatePivot draws object with its top left corner at x, y,
+// turned around the pivot, given as fractions of its size.
+func RotateAndTranslatePivot(angle float64, object *ebiten.Image, screen *ebiten.Image, x, y, pivotX, pivotY float64) {
+	px := float64(object.Bounds().Dx()) * pivotX
+	py := float64(object.Bounds().Dy()) * pivotY
 	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-px, -py)
 	op.GeoM.Rotate(angle)
-	op.GeoM.Translate(x, y)
-	//vector.StrokeRect(screen, float32(x), float32(y), float32(w), float32(h), 2, color.RGBA{255, 255, 255, 255}, false)
+	op.GeoM.Translate(x+px, y+py)
 	screen.DrawImage(object, op)
 }
 
@@ -41,6 +43,6 @@ func ScaleImg(img *ebiten.Image, scale float64) *ebiten.Image {
 	return nil
 }
 
-func RandInt(min, max int) int {
-	return min + rand.Intn(max-min)
+func RandInt(r *rand.Rand, min, max int) int {
+	return min + r.Intn(max-min)
 }
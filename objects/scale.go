@@ -0,0 +1,41 @@
+package objects
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+type scaledKey struct {
+	src   *ebiten.Image
+	scale float64
+}
+
+// SpriteCache scales every source image only once per scale factor. It is
+// cleared when the resolution changes, so sprites are always derived from the
+// original image and never from an already scaled one.
+type SpriteCache struct {
+	sprites map[scaledKey]*ebiten.Image
+}
+
+func NewSpriteCache() *SpriteCache {
+	return &SpriteCache{
+		sprites: make(map[scaledKey]*ebiten.Image),
+	}
+}
+
+func (c *SpriteCache) Scaled(src *ebiten.Image, scale float64) *ebiten.Image {
+	if src == nil {
+		return nil
+	}
+	if scale == 1 {
+		return src
+	}
+	key := scaledKey{src, scale}
+	if img, ok := c.sprites[key]; ok {
+		return img
+	}
+	img := ScaleImg(src, scale)
+	c.sprites[key] = img
+	return img
+}
+
+func (c *SpriteCache) Clear() {
+	clear(c.sprites)
+}